@@ -0,0 +1,99 @@
+package astrocam
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* isJPEG returns true if file name has a jpeg extension */
+func isJPEG(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".jpg" || ext == ".jpeg"
+}
+
+/* PreviewAccumulator maintains a running per-pixel average of jpeg frames */
+type PreviewAccumulator struct {
+	bounds image.Rectangle
+	sum    []float64
+	count  int
+}
+
+/* Add updates running average with the specified image */
+func (a *PreviewAccumulator) Add(img image.Image) error {
+	bounds := img.Bounds()
+	if a.count == 0 {
+		a.bounds = bounds
+		a.sum = make([]float64, bounds.Dx()*bounds.Dy()*3)
+	} else if bounds.Size() != a.bounds.Size() {
+		return fmt.Errorf("frame size %v does not match preview size %v", bounds.Size(), a.bounds.Size())
+	}
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			a.sum[i] += float64(r >> 8)
+			a.sum[i+1] += float64(g >> 8)
+			a.sum[i+2] += float64(b >> 8)
+			i += 3
+		}
+	}
+	a.count++
+	return nil
+}
+
+/* AddFile decodes jpeg file and adds it to the running average */
+func (a *PreviewAccumulator) AddFile(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	img, err := jpeg.Decode(fh)
+	if err != nil {
+		return fmt.Errorf("AddFile(%s): %w", path, err)
+	}
+	return a.Add(img)
+}
+
+/* Mean returns current average image */
+func (a *PreviewAccumulator) Mean() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, a.bounds.Dx(), a.bounds.Dy()))
+	if a.count == 0 {
+		return img
+	}
+	i := 0
+	for y := 0; y < a.bounds.Dy(); y++ {
+		for x := 0; x < a.bounds.Dx(); x++ {
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(a.sum[i] / float64(a.count)),
+				G: uint8(a.sum[i+1] / float64(a.count)),
+				B: uint8(a.sum[i+2] / float64(a.count)),
+				A: 0xff,
+			})
+			i += 3
+		}
+	}
+	return img
+}
+
+/* WritePNG writes current average image to the specified png file */
+func (a *PreviewAccumulator) WritePNG(path string) error {
+	if a.count == 0 {
+		return nil
+	}
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(fh, a.Mean()); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
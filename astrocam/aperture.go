@@ -0,0 +1,71 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/* ApertureTolerance is the largest difference between requested and enumerated aperture considered an exact match */
+const ApertureTolerance = 0.05
+
+/* parseAperture parses enumerated aperture choice such as "2.8" or "f/4" */
+func parseAperture(choice string) (float64, error) {
+	value := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(choice)), "f/")
+	return strconv.ParseFloat(value, 64)
+}
+
+/* nearestAperture returns enumerated aperture choice closest to the requested f-number */
+func nearestAperture(req float64, choices []string) (string, error) {
+	best := ""
+	bestDiff := math.Inf(1)
+	for _, choice := range choices {
+		value, err := parseAperture(choice)
+		if err != nil {
+			/* skip non numeric choices such as "implicit auto" */
+			continue
+		}
+		if diff := math.Abs(value - req); diff < bestDiff {
+			best, bestDiff = choice, diff
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("nearestAperture: no numeric aperture choices (choices: %s)", strings.Join(choices, ", "))
+	}
+	return best, nil
+}
+
+/* setAperture sets camera aperture to the enumerated choice nearest to requested f-number */
+func (c *Camera) setAperture() error {
+	setting, err := c.camera.GetSetting("aperture")
+	if errors.Is(err, ErrNotSupported) {
+		/* manual lenses have no electronic aperture */
+		log.Printf("Warning: camera has no aperture setting, set f/%g on the lens\n", c.Aperture)
+		return nil
+	}
+	if err != nil {
+		return cameraError("setAperture", err)
+	}
+	choices, err := setting.Options()
+	if err != nil || len(choices) == 0 {
+		/* choices are not enumerated, let the camera interpret the value */
+		return c.SetConfig("aperture", strconv.FormatFloat(c.Aperture, 'f', 1, 32))
+	}
+	choice, err := nearestAperture(c.Aperture, choices)
+	if err != nil {
+		return err
+	}
+	value, _ := parseAperture(choice)
+	if math.Abs(value-c.Aperture) > ApertureTolerance {
+		log.Printf("Warning: aperture f/%g is not available, using nearest f/%g\n", c.Aperture, value)
+	}
+	if err := c.SetConfig("aperture", choice); err != nil {
+		return err
+	}
+	/* metadata reports the aperture actually set */
+	c.Aperture = value
+	return nil
+}
@@ -0,0 +1,1228 @@
+package astrocam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EosRemoteRelease = "eosremoterelease"
+	BatteryLevel     = "batterylevel"
+	ISOAuto          = "Auto"
+
+	/* ClockJumpThreshold is the maximum tolerated deviation between countdown ticks */
+	ClockJumpThreshold = time.Second * 5
+
+	/* RunningPreviewName is the file name of the running average preview in kind directory */
+	RunningPreviewName = "running-preview.png"
+
+	/* ListRetries is the number of retries of failed camera file listing */
+	ListRetries = 2
+	/* ListRetryDelay is the pause between camera file listing attempts */
+	ListRetryDelay = time.Second
+
+	/* DateLayout is the format of date directory names */
+	DateLayout = "2006-01-02"
+)
+
+/* ErrNoMemoryCard is returned when camera reports no storage to capture frames to */
+var ErrNoMemoryCard = errors.New("no memory card detected")
+
+/* CameraFiles is a list of files in CameraFilePath format */
+type CameraFiles []gphoto2.CameraFilePath
+
+/* LoadCameraFiles retrieves a list of files stored in the camera */
+func (c *CameraFiles) LoadCameraFiles(camera Backend) error {
+	/* list files on camera */
+	storage, err := camera.ListFiles()
+	if err != nil {
+		return err
+	}
+	/* frames are captured to the memory card, so missing card would silently lose all frames */
+	if len(storage) == 0 {
+		return ErrNoMemoryCard
+	}
+	/* walk through camera files */
+	for _, device := range storage {
+		for _, container := range device.Children {
+			for _, directory := range container.Children {
+				for _, file := range directory.Children {
+					if !file.Dir {
+						*c = append(*c, file)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/* Contains returns true if CameraFiles list contains specified file */
+func (c CameraFiles) Contains(file gphoto2.CameraFilePath) bool {
+	for _, f := range c {
+		if f.Name == file.Name {
+			return true
+		}
+	}
+	return false
+}
+
+/* FindNew returns list of new items in files that do not exist in CameraFiles list c */
+func (c *CameraFiles) FindNew(files *CameraFiles) *CameraFiles {
+	result := new(CameraFiles)
+	if len(*c) == len(*files) {
+		return result
+	}
+	for _, newFile := range *files {
+		if !c.Contains(newFile) {
+			*result = append(*result, newFile)
+		}
+	}
+	return result
+}
+
+/* Camera runs capture sessions on a camera connected through Backend */
+type Camera struct {
+	camera   Backend
+	Label    string
+	Model    string
+	Lens     string
+	Battery  string
+	ISO      string
+	Aperture float64
+	Shutter  string
+	Duration int
+	Frames   int
+	Current  int
+	Target   string
+	Kind     string
+	Keep     bool
+	Files    CameraFiles
+
+	AssumeYes     bool
+	SkipDriveMode bool
+	SelfTimer     int
+	OptimizePower bool
+
+	DeletePolicy string
+	DeleteDelay  time.Duration
+
+	ShutterRating int
+
+	noShots     bool
+	shotsWarned bool
+
+	BatteryPerFrame float64
+	batteryWarned   bool
+	ACPower         bool
+	externalPower   bool
+
+	Append        bool
+	DeferDownload bool
+	NewRun        bool
+	resumed       int
+	runDir        string
+
+	Sweep        bool
+	DurationRamp Ramp
+	ISORamp      Ramp
+	ISOBracket   ISOBracket
+	Bracket      DurationBracket
+	Brackets     DurationBracket
+	bracket      int
+	subDir       string
+
+	name            string
+	PowerCycleCmd   string
+	PowerCycleDelay time.Duration
+
+	Info     Info
+	JSONInfo bool
+
+	DarkEvery  int
+	darkFrames int
+	follows    int
+
+	checksums    map[string]string
+	pending      *FrameRecord
+	MaxFrameSize int64
+
+	Clouds        CloudDetector
+	Ephemeris     EphemerisGate
+	CloudTimeout  time.Duration
+	CloudInterval time.Duration
+
+	ToDNG      bool
+	DNGCmd     string
+	DNGKeepRaw bool
+	dng        *DNGConverter
+
+	FITS        RawToFITS
+	FITSKeepRaw bool
+	Telescope   string
+
+	MinFreeSpace   float64
+	Sink           Sink
+	RateLimit      int64
+	RateLimitLocal bool
+	stored         []string
+
+	PretriggerDelay time.Duration
+	ExposureStatus  string
+	ReleaseHold     time.Duration
+	idleStatus      string
+	Cadence         time.Duration
+	CadenceStrict   bool
+	cadenceFrames   int
+	NoReset         bool
+
+	CooldownEvery    int
+	CooldownDuration time.Duration
+	Delay            int
+
+	DitherCmd    string
+	DitherEvery  int
+	DitherSettle int
+
+	HealthFile          string
+	Retries             int
+	MaxDownloadFailures int
+	downloadFailures    int
+	USBTimeout          time.Duration
+	SettingTimeout      time.Duration
+	USBChunkSize        int
+	ConnectTimeout      time.Duration
+	ConnectInterval     time.Duration
+
+	DateLayout bool
+	DateRotate bool
+	frameDir   string
+
+	ImageFormat    string
+	Kelvin         int
+	Filter         string
+	Filters        FilterPlan
+	Plan           *Plan
+	FilterWheel    FilterWheel
+	AstroBinCSV    string
+	PictureStyle   string
+	PairLayout     string
+	RunningPreview int
+	Crop           Crop
+	Sidecar        bool
+	preview        *PreviewAccumulator
+
+	Timelapse    bool
+	TimelapseFPS int
+	FFmpeg       string
+
+	UseInternalBulb bool
+	internalBulb    string
+
+	SanityCheck bool
+	SanityAbort bool
+
+	Location       *Location
+	LocationSource LocationSource
+
+	StatusOutput *StatusWriter
+	Metrics      *Metrics
+	Events       *EventWriter
+	FrameLog     *FrameLog
+	OnEvent      func(Event)
+	TUI          *Dashboard
+
+	saved    []savedSetting
+	Explicit map[string]bool
+	Stop     *StopSignal
+
+	AutoFlats       bool
+	FlatsBrightness float64
+	SaveTestFrames  bool
+
+	ReleaseSequence ReleaseSequence
+	PrefireSequence ReleaseSequence
+	MirrorPrefire   time.Duration
+
+	RefocusEvery     int
+	RefocusThreshold float64
+	AutofocusCmd     string
+	FocusBaseline    float64
+
+	Summary SessionSummary
+}
+
+/* SetConfig configures integer camera setting */
+func (c *Camera) SetConfig(CameraSetting string, value string) error {
+	setting, err := c.camera.GetSetting(CameraSetting)
+	if err != nil {
+		return cameraError("SetConfig("+CameraSetting+")", err)
+	}
+	if err := setting.Set(value); err != nil {
+		return cameraError("SetConfig("+CameraSetting+")", err)
+	}
+	return nil
+}
+
+/* GetBatteryStatus retrieves current battery status */
+func (c *Camera) GetBatteryStatus() (level string, err error) {
+	battery, err := c.camera.GetSetting(BatteryLevel)
+	if err != nil {
+		return "", cameraError("GetBatteryStatus", err)
+	}
+	v, err := battery.Get()
+	if err != nil {
+		return "", cameraError("GetBatteryStatus", err)
+	}
+	return v.(string), nil
+}
+
+/* prefix returns camera label prefix for output lines in multi camera sessions */
+func (c *Camera) prefix() string {
+	if c.Label == "" {
+		return ""
+	}
+	return c.Label + ": "
+}
+
+/* Status generates a real-time frame capture status */
+func (c *Camera) Status(frame int, seconds int) string {
+	if c.Frames == 0 {
+		return fmt.Sprintf(
+			"%sCapturing %s frame %3d; %3d seconds remaining; battery: %s",
+			c.prefix(),
+			c.Kind,
+			frame,
+			seconds,
+			c.Battery,
+		)
+	}
+	return fmt.Sprintf(
+		"%sCapturing %s frame %3d/%d; %3d seconds remaining; battery: %s",
+		c.prefix(),
+		c.Kind,
+		frame,
+		c.Frames,
+		seconds,
+		c.Battery,
+	)
+}
+
+/* WaitExposure prints countdown status and blocks until the exposure deadline passes, done is closed or context is cancelled */
+func (c *Camera) WaitExposure(ctx context.Context, frame int, done <-chan struct{}) {
+	/* use wall clock deltas rather than accumulated sleeps so suspend/resume can not stretch the exposure */
+	now := time.Now().Round(0)
+	end := now.Add(time.Second * time.Duration(c.Duration))
+	expected := time.Duration(0)
+	reported := math.MaxInt
+	for last := now; now.Before(end); last = now {
+		left := end.Sub(now)
+		/* throttle status updates to one per remaining second and never report stale or negative values */
+		if seconds := int(math.Ceil(left.Seconds())); seconds < reported && seconds > 0 {
+			c.report(frame, seconds)
+			reported = seconds
+		}
+		/* sleep until next tick or exposure end, whichever comes first */
+		if left > time.Second {
+			left = time.Second
+		}
+		expected = left
+		timer := time.NewTimer(left)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		now = time.Now().Round(0)
+		/* detect clock jumps between two countdown ticks */
+		if drift := now.Sub(last) - expected; drift > ClockJumpThreshold || drift < -ClockJumpThreshold {
+			log.Printf("Warning: clock jump of %v detected during exposure of frame %d\n", drift, frame)
+		}
+	}
+	time.Sleep(time.Millisecond * 100)
+}
+
+/* sleepContext pauses for the specified duration, returning context error early when it is cancelled */
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+/* wait pauses between frames for the specified duration, it ends early without error when stop is requested */
+func (c *Camera) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.Stop.Done():
+		return nil
+	case <-timer.C:
+		return nil
+	}
+}
+
+/* listFiles retrieves list of files on the camera, retrying a couple of times on failure */
+func (c *Camera) listFiles() (files *CameraFiles, err error) {
+	for attempt := 0; attempt <= ListRetries; attempt++ {
+		if attempt != 0 {
+			log.Printf("Warning: listing camera files failed, retrying: %v\n", err)
+			c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.Retries++ })
+			time.Sleep(ListRetryDelay)
+		}
+		files = new(CameraFiles)
+		if err = files.LoadCameraFiles(c.camera); err == nil {
+			return files, nil
+		}
+		/* only transient failures are worth retrying */
+		if err = cameraError("listFiles", err); !isTransient(err) {
+			break
+		}
+	}
+	return nil, err
+}
+
+/* targetPath returns directory for frames captured at the specified time */
+func (c *Camera) targetPath(at time.Time) string {
+	if !c.DateLayout {
+		return filepath.Join(c.Target, c.Kind, c.runDir, c.subDir)
+	}
+	/* without rotation all frames go to the folder of session start date */
+	if !c.DateRotate && !c.Summary.Start.IsZero() {
+		at = c.Summary.Start
+	}
+	return filepath.Join(c.Target, at.Format(DateLayout), c.Kind, c.runDir, c.subDir)
+}
+
+/* framePath returns local path of the downloaded camera file in current frame directory */
+func (c *Camera) framePath(name string) string {
+	return filepath.Join(c.frameDir, name)
+}
+
+/* CaptureBulb instructs camera to capture image with the specified duration in BULB mode */
+func (c *Camera) CaptureBulb(ctx context.Context, frame int) error {
+	c.pending = nil
+	/* get current battery status and location */
+	c.readBattery()
+	c.updateLocation()
+	/* stagger exposure start, delay precedes exposure so it does not shorten it */
+	if err := sleepContext(ctx, c.PretriggerDelay); err != nil {
+		return fmt.Errorf("CaptureBulb: %w", err)
+	}
+	/* expose frame */
+	iso := c.confirmedISO()
+	record := FrameRecord{Frame: frame, Method: ExposureHost, ISO: iso, Location: c.Location, Kind: c.Kind, Follows: c.follows, Filter: c.Filter}
+	/* frame directory is computed per frame so that date folders rotate at local midnight */
+	now := time.Now()
+	c.frameDir = c.targetPath(now)
+	record.Dir = c.frameDir
+	if err := os.MkdirAll(c.frameDir, 0755); err != nil {
+		return err
+	}
+	c.emit(Event{Type: EventFrameStart, Time: now, Frame: frame})
+	/* exposure records when the shutter actually opened and closed */
+	if c.internalBulb != "" {
+		record.Method = ExposureInternal
+		if err := c.exposeInternal(ctx, frame, &record); err != nil {
+			return err
+		}
+	} else if err := c.exposeHost(ctx, frame, &record); err != nil {
+		return err
+	}
+	c.emit(Event{Type: EventFrameEnd, Time: record.End, Frame: frame})
+	if c.Shutter == "bulb" {
+		record.Requested = time.Second * time.Duration(c.Duration)
+	}
+	/* frame is on the card from now on, power cycle collects it instead of exposing it again */
+	c.pending = &record
+	return c.collectFrame(ctx, record)
+}
+
+/* collectFrame waits until exposed frame is written to the card, downloads its files and records it in session summary */
+func (c *Camera) collectFrame(ctx context.Context, record FrameRecord) error {
+	frame := record.Frame
+	/* wait for camera to finish writing frame to the card */
+	timing := TimingFor(c.Kind)
+	time.Sleep(timing.PostExposureWait)
+	/* reset camera connection, many bodies list new files without it so failure is not fatal */
+	if !c.NoReset {
+		reset := func() error { return cameraError("Reset", c.camera.Reset()) }
+		if err := c.withRetry(c.Retries+1, reset); err != nil {
+			log.Printf("Warning: camera reset failed, continuing without reset: %v\n", err)
+		}
+	}
+	/* the gphoto2 binding has no wait for event call, so new files are detected by listing the card */
+	/* get new list of files on the camera, rescan if frame is not written yet */
+	newFiles := new(CameraFiles)
+	for scan := 0; len(*newFiles) == 0 && scan <= timing.ScanRetries; scan++ {
+		if scan != 0 {
+			time.Sleep(timing.ScanInterval)
+		}
+		files, err := c.listFiles()
+		if err != nil {
+			return err
+		}
+		newFiles = c.Files.FindNew(files)
+	}
+	/* files stay on the card until the session ends */
+	if c.DeferDownload {
+		c.deferFiles(&record, *newFiles)
+		c.pending = nil
+		return nil
+	}
+	/* a glitch or a manual shot may leave more files than a single exposure produces */
+	current, extra := c.splitUnexpected(*newFiles)
+	downloaded, failed := make(map[string]bool), make(map[string]bool)
+	downloadErr := c.downloadUnexpected(ctx, extra, downloaded)
+	pairs := findPairs(current)
+	reconnected := false
+	for i := 0; i < len(current); i++ {
+		file := current[i]
+		/* make room for the frame by removing local copies of frames stored by sink */
+		if err := c.ensureFreeSpace(); err != nil {
+			return err
+		}
+		/* download frame */
+		path := uniquePath(bracketPath(buildLocalPath(c.frameDir, file.Name, pairs, c.PairLayout), c.bracket))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		/* raw frames converted to fits are downloaded to memory and the fits file becomes the frame */
+		var err error
+		if c.FITS != nil && isRaw(file.Name) {
+			path, err = c.downloadFITS(ctx, file, path, &record)
+		} else {
+			err = c.downloadFile(ctx, file, path)
+		}
+		name, _ := filepath.Rel(c.frameDir, path)
+		if err != nil {
+			/* oversized files are skipped and left on the camera instead of aborting the session */
+			if errors.Is(err, ErrFrameTooLarge) {
+				log.Printf("Error: %v, skipping file\n", err)
+				continue
+			}
+			/* partial file is already removed and the frame is still on the card, pull it again after reconnecting */
+			if needsPowerCycle(err) && c.PowerCycleCmd != "" && !reconnected {
+				reconnected = true
+				if current, err = c.reconnectFiles(frame, current, err); err == nil {
+					i--
+					continue
+				}
+			}
+			log.Printf("Error: %v, leaving file on the camera\n", err)
+			failed[file.Name] = true
+			c.Summary.Failed = append(c.Summary.Failed, FailedDownload{Time: time.Now(), Frame: frame, File: file.Name, Err: err})
+			if downloadErr == nil {
+				downloadErr = err
+			}
+			continue
+		}
+		downloaded[file.Name] = true
+		/* keep frames failing validation apart from good ones, they are safe to remove from the card */
+		if err := c.validateFrame(path); err != nil {
+			log.Printf("Warning: rejecting %s: %v\n", file.Name, err)
+			if err := c.quarantine(path, err.Error()); err != nil {
+				log.Printf("Warning: %v\n", err)
+			}
+			continue
+		}
+		/* checksum of converted raw frames is written once conversion decides which files are kept */
+		convert := c.dng != nil && isRaw(file.Name)
+		if !convert {
+			if err := c.appendChecksum(path); err != nil {
+				log.Printf("Warning: %v\n", err)
+			}
+		}
+		c.storeFrame(path)
+		/* update running preview with downloaded jpeg frames, interleaved darks are excluded */
+		if c.preview != nil && record.Follows == 0 && isJPEG(file.Name) {
+			if err := c.preview.AddFile(path); err != nil {
+				log.Printf("Warning: running preview: %v\n", err)
+			}
+		}
+		/* show intended framing on the latest jpeg light */
+		if c.Crop.Enabled() && record.Follows == 0 && isJPEG(file.Name) {
+			if err := c.writeCropPreview(path); err != nil {
+				log.Printf("Warning: crop preview: %v\n", err)
+			}
+		}
+		record.Files = append(record.Files, name)
+		/* read back actual exposure time and iso */
+		if info, err := readExif(path); err == nil {
+			if record.Actual == 0 {
+				record.Actual = info.ExposureTime
+			}
+			if info.ISO != 0 {
+				record.ISO = strconv.Itoa(info.ISO)
+			}
+		}
+		/* raw is converted in background once it is no longer needed here */
+		if convert {
+			c.dng.Queue(path, c.takeChecksum(path))
+		}
+	}
+	/* ask the body for iso chosen by auto iso if exif was not readable */
+	if strings.EqualFold(record.ISO, ISOAuto) {
+		if iso, err := c.GetConfig("iso"); err == nil {
+			record.ISO = iso
+		}
+	}
+	/* remove new files from the card according to delete policy, files which failed to download are kept for recovery */
+	remove := CameraFiles{}
+	for _, file := range *newFiles {
+		if failed[file.Name] {
+			c.Files = append(c.Files, file)
+		} else {
+			remove = append(remove, file)
+		}
+	}
+	if err := c.deleteNewFiles(remove, downloaded); err != nil {
+		return err
+	}
+	/* a single unreadable file does not end the session, repeated failures do */
+	if downloadErr != nil {
+		c.downloadFailures++
+		if c.MaxDownloadFailures > 0 && c.downloadFailures >= c.MaxDownloadFailures {
+			return fmt.Errorf("CaptureBulb: %d consecutive frames failed to download: %w", c.downloadFailures, downloadErr)
+		}
+		log.Printf("Warning: %sframe %d is incomplete, continuing session\n", c.prefix(), frame)
+	} else {
+		c.downloadFailures = 0
+	}
+	/* fall back to host measured exposure time between shutter open and close */
+	if record.Actual == 0 {
+		record.Actual = record.End.Sub(record.Start)
+	}
+	c.Summary.Records = append(c.Summary.Records, record)
+	c.frameDone(record)
+	c.logFrame(record)
+	/* interleaved darks always get a sidecar so they can be matched with lights later */
+	if (c.Sidecar || record.Follows != 0) && len(record.Files) != 0 {
+		if err := c.writeSidecar(record); err != nil {
+			return err
+		}
+	}
+	c.pending = nil
+	return nil
+}
+
+/* Close camera and free memory, restoring changed settings first */
+func (c *Camera) Close() error {
+	/* record shutter count at session end when it was available at start */
+	if c.Summary.ShutterStart != 0 {
+		if count, ok := c.readShutterCount(); ok {
+			c.Summary.ShutterEnd = count
+			log.Printf("%sShutter count: %d\n", c.prefix(), count)
+		}
+	}
+	restoreErr := c.restoreSettings()
+	if err := c.camera.Exit(); err != nil {
+		return err
+	}
+	if err := c.camera.Free(); err != nil {
+		return err
+	}
+	return restoreErr
+}
+
+/* Connect opens connection to the named camera, polling until it appears or connect timeout elapses */
+func (c *Camera) Connect(name string) (err error) {
+	deadline := time.Now().Add(c.ConnectTimeout)
+	for attempt := 1; ; attempt++ {
+		if c.camera, err = openCamera(name); err == nil {
+			return nil
+		}
+		if time.Now().Add(c.ConnectInterval).After(deadline) {
+			return fmt.Errorf("connect: %w", err)
+		}
+		log.Printf("Camera not available (attempt %d): %v, retrying in %v\n", attempt, err, c.ConnectInterval)
+		time.Sleep(c.ConnectInterval)
+	}
+}
+
+/* Initialize camera settings before shooting session */
+//func (c *Camera) Initialize(frames uint32, duration, iso int, shutter string, aperture float64, target, kind string, keep bool) error {
+func (c *Camera) Init(name string) (err error) {
+	/* initialize camera parameters, name is kept for reinitialization after power cycle */
+	c.name = name
+	c.checksums = make(map[string]string)
+	if err = c.Connect(name); err != nil {
+		return err
+	}
+	/* tune port i/o before any downloads */
+	c.applyUSBTuning()
+	/* get camera model, lens name and initial camera files, first reads hang on a wedged usb bus */
+	var model, lens string
+	files := CameraFiles{}
+	err = c.watchdog("Init", func() error {
+		model = c.infoConfig("cameramodel")
+		lens = c.infoConfig("lensname")
+		return files.LoadCameraFiles(c.camera)
+	})
+	if err != nil {
+		return fmt.Errorf("Init(files): %w", err)
+	}
+	c.Model, c.Lens = model, lens
+	c.Files = append(c.Files, files...)
+
+	fmt.Printf("Initializing camera: %s... ", c.Model)
+	if err := c.SetConfig("focusmode", "Manual"); err != nil {
+		fmt.Printf("Error!\n")
+		return fmt.Errorf("Init(focusmode): %w", err)
+	}
+	if c.SelfTimer > 0 {
+		/* self-timer drive mode delays exposure start after every release */
+		drive, err := c.selfTimerDrive()
+		if err != nil {
+			fmt.Printf("Error!\n")
+			return fmt.Errorf("Init(drivemode): %w", err)
+		}
+		if err := c.rememberConfig("drivemode", drive); err != nil {
+			fmt.Printf("Error!\n")
+			return fmt.Errorf("Init(drivemode): %w", err)
+		}
+	} else if !c.SkipDriveMode {
+		/* avoid double triggers with continuous drive modes */
+		single, err := c.findChoice("drivemode", "Single")
+		if err != nil {
+			fmt.Printf("Error!\n")
+			return fmt.Errorf("Init(drivemode): %w", err)
+		}
+		if err := c.rememberConfig("drivemode", single); err != nil {
+			fmt.Printf("Error!\n")
+			return fmt.Errorf("Init(drivemode): %w", err)
+		}
+	}
+	if err := c.applyKindSettings(c.Kind); err != nil {
+		fmt.Printf("Error!\n")
+		return err
+	}
+	/* consistent previews for metering and sanity checks */
+	if err := c.setPictureStyle(); err != nil {
+		fmt.Printf("Error!\n")
+		return fmt.Errorf("Init(picturestyle): %w", err)
+	}
+	if err := c.SetConfig("capturetarget", "Memory card"); err != nil {
+		fmt.Printf("Error!\n")
+		return fmt.Errorf("Init(capturetarget): %w (is a memory card inserted?)", err)
+	}
+	/* keep camera awake between frames */
+	if c.OptimizePower {
+		c.optimizePower()
+	}
+	/* get current battery status */
+	c.Battery = c.infoConfig(BatteryLevel)
+	c.detectExternalPower()
+	/* track mechanical shutter wear */
+	c.checkShutterCount()
+	/* warn early when planned frames do not fit on the card */
+	c.checkAvailableShots(c.Frames)
+	c.checkBatteryFrames(c.Frames)
+	/* prefer camera timed bulb exposures when requested and supported */
+	if c.UseInternalBulb {
+		if c.internalBulb = c.detectInternalBulb(); c.internalBulb == "" {
+			fmt.Printf("internal bulb timer not supported, using host timing... ")
+		}
+	}
+	/* startup info is kept for the banner and wrapper scripts */
+	c.Info = Info{
+		Time:          time.Now(),
+		Camera:        c.Label,
+		Model:         c.Model,
+		Lens:          c.Lens,
+		Files:         len(c.Files),
+		Battery:       c.Battery,
+		BatteryFrames: c.estimateRemainingFrames(),
+		ExternalPower: c.externalPower,
+	}
+	fmt.Printf("Done.\n")
+	return nil
+}
+
+/* refreshSnapshot re-reads camera files right before capture, so test shots taken since Init are not downloaded as frames */
+func (c *Camera) refreshSnapshot() error {
+	files, err := c.listFiles()
+	if err != nil {
+		return fmt.Errorf("refreshSnapshot: %w", err)
+	}
+	if added := c.Files.FindNew(files); len(*added) != 0 {
+		log.Printf("Warning: %d files appeared on the card since initialization, they will not be downloaded\n", len(*added))
+	}
+	c.Files = *files
+	return nil
+}
+
+/* captureFrame captures a single frame, a persistent i/o error is retried once after power cycling the camera */
+func (c *Camera) captureFrame(ctx context.Context, frame int) error {
+	err := c.CaptureBulb(ctx, frame)
+	if err != nil && c.PowerCycleCmd != "" && needsPowerCycle(err) {
+		if err := c.powerCycle(frame, err); err != nil {
+			return err
+		}
+		/* frame exposed before the failure is collected from the card instead of exposing it again */
+		if c.pending != nil {
+			return c.collectFrame(ctx, *c.pending)
+		}
+		if err := c.collectUnexpected(ctx); err != nil {
+			return err
+		}
+		err = c.CaptureBulb(ctx, frame)
+	}
+	return err
+}
+
+/* CaptureLoop performs frames capture with specified parameters until done or context is cancelled between frames */
+func (c *Camera) CaptureLoop(ctx context.Context) error {
+	/* sweeps run several capture loops within one session */
+	if c.Summary.Start.IsZero() {
+		c.Summary.Start = time.Now()
+		c.Summary.Crop = c.crop()
+	}
+	c.frameDir = c.targetPath(c.Summary.Start)
+	if c.RunningPreview > 0 {
+		c.preview = new(PreviewAccumulator)
+	}
+	c.saveState()
+	if err := c.touchHealthFile(); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+	/* capture loop */
+	for frame := c.resumed; c.Frames == 0 || frame < c.Frames; frame++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("CaptureLoop: %w", err)
+		}
+		if c.stopped(frame) {
+			break
+		}
+		if c.Frames > 0 {
+			c.checkAvailableShots(c.Frames - frame)
+			c.checkBatteryFrames(c.Frames - frame)
+		}
+		/* pause while clouds pass, the previous frame is always complete at this point */
+		if c.Clouds != nil {
+			if err := c.waitClearSky(ctx, frame+1); err != nil {
+				return err
+			}
+		}
+		/* pause while ephemeris does not allow capture, e.g. moon is up */
+		if c.Ephemeris != nil {
+			if err := c.waitEphemeris(ctx, frame+1); err != nil {
+				return err
+			}
+		}
+		/* keep exact frame spacing */
+		if c.Cadence > 0 {
+			if err := c.waitCadence(ctx); err != nil {
+				return err
+			}
+		}
+		/* interrupts during waits do not start another frame */
+		if c.stopped(frame) {
+			break
+		}
+		/* transition timelapse changes exposure smoothly across frames */
+		if err := c.applyRamps(frame); err != nil {
+			return err
+		}
+		/* perform frame capture, bracketed frames are exposed once for every bracket duration */
+		if err := c.captureBrackets(ctx, frame+1); err != nil {
+			c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.FramesFailed++ })
+			return err
+		}
+		c.Summary.Frames++
+		c.saveState()
+		if err := c.touchHealthFile(); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+		c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.FramesCaptured++; m.Remaining = 0 })
+		/* write "stacked so far" preview every N frames */
+		if c.preview != nil && (frame+1)%c.RunningPreview == 0 {
+			if err := c.preview.WritePNG(c.framePath(RunningPreviewName)); err != nil {
+				log.Printf("Warning: running preview: %v\n", err)
+			}
+		}
+		/* nothing follows the downloaded frame once stop is requested */
+		if c.Stop.Stopped() {
+			continue
+		}
+		/* interleave a dark frame every N lights */
+		if c.DarkEvery > 0 && (frame+1)%c.DarkEvery == 0 {
+			if err := c.captureInterleavedDark(ctx, frame+1); err != nil {
+				return err
+			}
+		}
+		/* periodic focus check does not affect frame numbering */
+		if c.RefocusEvery > 0 && (frame+1)%c.RefocusEvery == 0 {
+			if err := c.CheckFocus(frame + 1); err != nil {
+				log.Printf("Warning: focus check failed: %v\n", err)
+			}
+		}
+		/* dither mount between frames, the next exposure starts after the mount has settled */
+		if c.DitherCmd != "" && (frame+1)%c.DitherEvery == 0 && (c.Frames == 0 || frame+1 < c.Frames) {
+			if err := c.Dither(); err != nil {
+				log.Printf("Warning: %v\n", err)
+			} else if err := c.settle(ctx, frame+1, c.DitherSettle); err != nil {
+				return err
+			}
+		}
+		/* thermal pacing, no cooldown follows the last frame */
+		if c.CooldownEvery > 0 && (frame+1)%c.CooldownEvery == 0 && (c.Frames == 0 || frame+1 < c.Frames) {
+			c.cooldown(ctx, frame+1)
+		}
+		/* let vibrations settle before the next exposure, the last frame is not followed by a delay */
+		if c.Delay > 0 && (c.Frames == 0 || frame+1 < c.Frames) {
+			if err := c.settle(ctx, frame+1, c.Delay); err != nil {
+				return err
+			}
+		}
+	}
+	c.Summary.End = time.Now()
+	fmt.Printf("\n\n%sFrames capture complete.\n", c.prefix())
+	return nil
+}
+
+/* stopped reports whether stop was requested on interrupt before the frame is started */
+func (c *Camera) stopped(frame int) bool {
+	if !c.Stop.Stopped() {
+		return false
+	}
+	fmt.Printf("\n%sStopped after frame %d.\n", c.prefix(), frame)
+	return true
+}
+
+/* Run initializes camera and runs capture session, camera is always closed and its settings restored on return */
+func (c *Camera) Run(ctx context.Context, name string) (err error) {
+	defer func() {
+		if c.camera == nil {
+			return
+		}
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	/* do not mix frames with a previous session */
+	if err := c.PrepareTarget(); err != nil {
+		return err
+	}
+	/* initialize camera */
+	if err := c.Init(name); err != nil {
+		return err
+	}
+
+	/* meter flats exposure */
+	if c.Kind == "flats" && c.AutoFlats {
+		if err := c.MeterFlats(); err != nil {
+			return err
+		}
+	}
+
+	/* print camera info */
+	if err := c.PrintInfo(); err != nil {
+		return err
+	}
+
+	/* preempt truncated or overwritten frames on exotic target storage */
+	c.checkFilesystem(c.sessionNames())
+
+	/* catch lights with lens cap on and darks with lens cap off */
+	if c.SanityCheck && (c.Kind == "lights" || c.Kind == "darks") {
+		if err := c.CheckSanity(); err != nil {
+			return err
+		}
+	}
+
+	c.emit(Event{Type: EventSessionStart, Message: c.Model})
+	defer func() {
+		if err != nil {
+			c.emit(Event{Type: EventError, Message: err.Error()})
+		}
+		c.emit(Event{Type: EventSessionEnd, Frame: c.Summary.Frames})
+	}()
+
+	/* session state is only needed to recover an interrupted session or files which failed to download */
+	defer func() {
+		if err == nil && len(c.Summary.Failed) == 0 {
+			os.Remove(c.statePath())
+		}
+	}()
+
+	/* convert raw frames in background, pending conversions finish before camera is closed */
+	if c.ToDNG {
+		c.dng = NewDNGConverter(c, c.DNGCmd, c.DNGKeepRaw)
+		defer c.dng.Wait()
+	}
+
+	/* files on the card may have changed while metering or waiting */
+	if err := c.refreshSnapshot(); err != nil {
+		return err
+	}
+
+	/* Perform frames capture */
+	if c.Sweep {
+		if err := c.SweepLoop(ctx); err != nil {
+			return err
+		}
+	} else if c.Plan != nil {
+		if err := c.runPlan(ctx, *c.Plan); err != nil {
+			return err
+		}
+	} else if len(c.Filters) != 0 {
+		if err := c.FilterLoop(ctx); err != nil {
+			return err
+		}
+	} else if err := c.CaptureLoop(ctx); err != nil {
+		return err
+	}
+
+	/* acquisition log for AstroBin import */
+	if c.AstroBinCSV != "" {
+		if err := c.writeAstroBinCSV(c.AstroBinCSV); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+	}
+
+	/* deferred frames are downloaded once capture is complete */
+	if c.DeferDownload {
+		if err := c.downloadDeferred(ctx); err != nil {
+			return err
+		}
+	}
+
+	/* assemble timelapse video, failures leave captured frames intact */
+	if c.Timelapse {
+		dir := c.frameDir
+		if c.PairLayout == PairsSplit {
+			if info, err := os.Stat(filepath.Join(dir, JPEGDir)); err == nil && info.IsDir() {
+				dir = filepath.Join(dir, JPEGDir)
+			}
+		}
+		if err := c.assembleTimelapse(dir, c.TimelapseFPS); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+/* KindPreset holds default capture parameters for a specific frame kind */
+type KindPreset struct {
+	Shutter  string
+	Duration int
+	ISO      string
+	/* lens is capped, so aperture does not matter and manual lenses would fail to set it */
+	SkipAperture bool
+}
+
+/* KindPresets maps frame kinds to default parameters; zero values keep global defaults (darks match lights) */
+var KindPresets = map[string]KindPreset{
+	"lights": {},
+	"darks":  {SkipAperture: true},
+	"flats":  {Shutter: "1/50", Duration: 1},
+	"bias":   {Shutter: "1/4000", Duration: 1, SkipAperture: true},
+}
+
+/* KindTiming holds timing of camera card access after exposure for a specific frame kind */
+type KindTiming struct {
+	PostExposureWait time.Duration
+	ScanRetries      int
+	ScanInterval     time.Duration
+}
+
+/* DefaultTiming is used for frame kinds without specific timing */
+var DefaultTiming = KindTiming{
+	PostExposureWait: time.Second * 2,
+	ScanRetries:      0,
+	ScanInterval:     time.Second,
+}
+
+/* KindTimings maps frame kinds to specific timing, darks are shot back to back with tighter timing */
+var KindTimings = map[string]KindTiming{
+	"darks": {
+		PostExposureWait: time.Millisecond * 500,
+		ScanRetries:      3,
+		ScanInterval:     time.Millisecond * 500,
+	},
+}
+
+/* TimingFor returns card access timing of the specified frame kind */
+func TimingFor(kind string) KindTiming {
+	if timing, ok := KindTimings[kind]; ok {
+		return timing
+	}
+	return DefaultTiming
+}
+
+/* confirmedISO reads back iso the camera is set to, so frames are not tagged with a requested value the body did not accept */
+func (c *Camera) confirmedISO() string {
+	iso, err := c.GetConfig("iso")
+	if err != nil {
+		log.Printf("Warning: unable to confirm iso, recording requested %s: %v\n", c.ISO, err)
+		return c.ISO
+	}
+	if !strings.EqualFold(c.ISO, ISOAuto) && iso != c.ISO {
+		log.Printf("Warning: %siso %s requested but camera reports %s\n", c.prefix(), c.ISO, iso)
+	}
+	return iso
+}
+
+/* applyKindSettings configures exposure settings which matter for the specified frame kind */
+func (c *Camera) applyKindSettings(kind string) (err error) {
+	if strings.EqualFold(c.Shutter, "bulb") {
+		if err := c.setBulbShutter(); err != nil {
+			return fmt.Errorf("Init(shutterspeed): %w", err)
+		}
+	} else if err := c.SetConfig("shutterspeed", c.Shutter); err != nil {
+		return fmt.Errorf("Init(shutterspeed): %w", err)
+	}
+	iso := c.ISO
+	if strings.EqualFold(iso, ISOAuto) {
+		/* auto iso is enumerated differently by camera bodies */
+		if iso, err = c.findChoice("iso", ISOAuto); err != nil {
+			return fmt.Errorf("Init(iso): %w", err)
+		}
+	}
+	if err := c.SetConfig("iso", iso); err != nil {
+		return fmt.Errorf("Init(iso): %w", err)
+	}
+	if err := c.setWhiteBalance(); err != nil {
+		return fmt.Errorf("Init(whitebalance): %w", err)
+	}
+	if err := c.SetConfig("imageformat", c.ImageFormat); err != nil {
+		return fmt.Errorf("Init(imageformat): %w", err)
+	}
+	if KindPresets[kind].SkipAperture {
+		return nil
+	}
+	if err := c.setAperture(); err != nil {
+		return fmt.Errorf("Init(aperture): %w", err)
+	}
+	return nil
+}
+
+/* applyKindDefaults applies kind presets to unset flags; precedence is: explicit flag > profile > preset > global default */
+func (c *Camera) applyKindDefaults() {
+	preset, ok := KindPresets[c.Kind]
+	if !ok {
+		return
+	}
+	if preset.Shutter != "" && !c.Explicit["shutter"] {
+		c.Shutter = preset.Shutter
+	}
+	if preset.Duration != 0 && !c.Explicit["duration"] {
+		c.Duration = preset.Duration
+	}
+	if preset.ISO != "" && !c.Explicit["iso"] {
+		c.ISO = preset.ISO
+	}
+}
+
+/* New creates camera session configured with default capture parameters, the same defaults as the command line uses */
+func New() *Camera {
+	return &Camera{
+		Target:              "/tmp/target",
+		Duration:            60,
+		Shutter:             "bulb",
+		Aperture:            2.8,
+		ISO:                 "800",
+		Kind:                "lights",
+		DeletePolicy:        DeleteDownloaded,
+		DeleteDelay:         time.Second,
+		RefocusThreshold:    20,
+		FlatsBrightness:     0.5,
+		ConnectInterval:     time.Second * 5,
+		PowerCycleDelay:     time.Second * 10,
+		CooldownDuration:    time.Minute * 5,
+		ReleaseHold:         time.Millisecond * 100,
+		MaxFrameSize:        1024,
+		SettingTimeout:      time.Second * 30,
+		MaxDownloadFailures: 3,
+		Retries:             3,
+		ImageFormat:         "RAW",
+		PairLayout:          PairsTogether,
+		DNGCmd:              "dnglab convert \"$1\" \"$2\"",
+		TimelapseFPS:        25,
+		DitherEvery:         1,
+		FFmpeg:              "ffmpeg",
+		BatteryPerFrame:     0.2,
+		CloudInterval:       time.Minute,
+		ReleaseSequence:     ReleaseSequence{ReleaseFull},
+		PrefireSequence:     ReleaseSequence{ReleasePressHalf, ReleaseHalf},
+		FilterWheel:         NoFilterWheel{},
+		Explicit:            make(map[string]bool),
+	}
+}
+
+/* Configure applies kind presets to parameters not set explicitly and validates capture parameters before a session is run */
+func (c *Camera) Configure() error {
+	if err := c.CheckDeletePolicy(); err != nil {
+		return err
+	}
+	if _, ok := KindPresets[c.Kind]; !ok {
+		return fmt.Errorf("Bad 'kind' option: %s (must be one of 'lights', 'darks', 'flats' or 'bias')", c.Kind)
+	}
+	if c.CooldownEvery > 0 && c.CooldownDuration <= 0 {
+		return fmt.Errorf("Option -cooldown-every requires positive -cooldown-duration")
+	}
+	if c.Delay < 0 {
+		return fmt.Errorf("Bad 'delay' option: %d (must not be negative)", c.Delay)
+	}
+	if c.DitherEvery < 1 {
+		return fmt.Errorf("Bad 'dither-every' option: %d (must be positive)", c.DitherEvery)
+	}
+	if c.DitherSettle < 0 {
+		return fmt.Errorf("Bad 'dither-settle' option: %d (must not be negative)", c.DitherSettle)
+	}
+	if c.MaxFrameSize < 0 {
+		return fmt.Errorf("Bad 'max-frame-size' option: %d (must not be negative)", c.MaxFrameSize)
+	}
+	if c.DarkEvery > 0 && c.Kind != "lights" {
+		return fmt.Errorf("Option -dark-every requires -kind=lights")
+	}
+	c.applyKindDefaults()
+	for _, check := range []func() error{
+		c.checkRamps,
+		c.checkPrefire,
+		c.checkSelfTimer,
+		c.checkKelvin,
+		c.checkUSBTuning,
+		c.checkPairLayout,
+	} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	if err := c.checkBrackets(); err != nil {
+		return err
+	}
+	if c.FITS != nil && c.ToDNG {
+		return fmt.Errorf("Options -fits and -to-dng are mutually exclusive")
+	}
+	if c.DeferDownload && (c.Sweep || c.DarkEvery > 0) {
+		return fmt.Errorf("Option -defer-download can not be used with -sweep or -dark-every")
+	}
+	if c.Append && c.NewRun {
+		return fmt.Errorf("Options -append and -new-run are mutually exclusive")
+	}
+	if c.Cadence > 0 && c.Cadence < time.Second*time.Duration(c.Duration) {
+		return fmt.Errorf("Option -cadence must not be shorter than -duration")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package astrocam
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+/* AstroBinHeader lists columns of AstroBin acquisition csv import */
+var AstroBinHeader = []string{"date", "filter", "number", "duration", "iso", "binning", "gain", "sensorCooling", "fNumber", "darks", "flats", "flatDarks", "bias", "bortle", "meanSqm", "meanFwhm", "temperature"}
+
+/* astroBinRow is a group of lights sharing date, filter and exposure settings */
+type astroBinRow struct {
+	Date     string
+	Filter   string
+	Duration string
+	ISO      string
+	Number   int
+}
+
+/* writeAstroBinCSV writes acquisition details of the session in AstroBin csv import format, calibration frames are counted per row */
+func (c *Camera) writeAstroBinCSV(path string) error {
+	rows := []*astroBinRow{}
+	index := make(map[astroBinRow]*astroBinRow)
+	calibration := make(map[string]int)
+	for _, record := range c.Summary.Records {
+		if record.Kind != "lights" {
+			calibration[record.Kind]++
+			continue
+		}
+		duration := record.Requested
+		if duration == 0 {
+			duration = record.Actual.Round(time.Millisecond)
+		}
+		key := astroBinRow{Date: record.Start.Format("2006-01-02"), Filter: record.Filter, Duration: strconv.FormatFloat(duration.Seconds(), 'f', -1, 64), ISO: record.ISO}
+		row, ok := index[key]
+		if !ok {
+			row = &astroBinRow{Date: key.Date, Filter: key.Filter, Duration: key.Duration, ISO: key.ISO}
+			index[key] = row
+			rows = append(rows, row)
+		}
+		row.Number++
+	}
+	/* calibration only sessions still produce a row holding frame counts */
+	if len(rows) == 0 && len(calibration) != 0 {
+		rows = append(rows, &astroBinRow{Date: c.Summary.Start.Format("2006-01-02"), Filter: c.Filter})
+	}
+	fh, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writeAstroBinCSV: %w", err)
+	}
+	defer fh.Close()
+	w := csv.NewWriter(fh)
+	w.Write(AstroBinHeader)
+	count := func(kind string) string {
+		if calibration[kind] == 0 {
+			return ""
+		}
+		return strconv.Itoa(calibration[kind])
+	}
+	for _, row := range rows {
+		w.Write([]string{
+			row.Date, row.Filter, strconv.Itoa(row.Number), row.Duration, row.ISO, "1", "", "",
+			strconv.FormatFloat(c.Aperture, 'f', -1, 64),
+			count("darks"), count("flats"), "", count("bias"), "", "", "", "",
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("writeAstroBinCSV: %w", err)
+	}
+	return fh.Close()
+}
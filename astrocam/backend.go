@@ -0,0 +1,61 @@
+package astrocam
+
+import (
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"io"
+)
+
+/* Widget is a single camera setting */
+type Widget interface {
+	Get() (interface{}, error)
+	Set(input interface{}) error
+	Options() ([]string, error)
+	Label() string
+	Type() gphoto2.WidgetType
+	ReadOnly() bool
+}
+
+/* Backend is the camera connection used by capture sessions, implemented by gphoto2 cameras; settings the body does not have are reported as ErrNotSupported */
+type Backend interface {
+	GetSetting(name string) (Widget, error)
+	ListFiles() ([]gphoto2.CameraStorageInfo, error)
+	CaptureImage() (*gphoto2.CameraFilePath, error)
+	CapturePreview(buffer io.Writer) error
+	Download(file gphoto2.CameraFilePath, buffer io.Writer) error
+	DeleteFile(path *gphoto2.CameraFilePath) error
+	Reset() error
+	Exit() error
+	Free() error
+}
+
+/* openCamera connects to the named camera, or the first one detected when name is empty */
+var openCamera = func(name string) (Backend, error) {
+	camera, err := gphoto2.NewCamera(name)
+	if err != nil {
+		return nil, err
+	}
+	return gphotoBackend{camera}, nil
+}
+
+/* gphotoBackend adapts gphoto2 camera to Backend */
+type gphotoBackend struct {
+	*gphoto2.Camera
+}
+
+/* GetSetting returns the named camera setting widget, binding reports missing setting as nil widget without error */
+func (b gphotoBackend) GetSetting(name string) (Widget, error) {
+	setting, err := b.Camera.GetSetting(name)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		return nil, &CameraError{Op: "GetSetting", Class: ErrBadValue, Err: fmt.Errorf("%s %w", name, ErrNotSupported)}
+	}
+	return setting, nil
+}
+
+/* Download copies camera file to buffer leaving it on the card */
+func (b gphotoBackend) Download(file gphoto2.CameraFilePath, buffer io.Writer) error {
+	return file.DownloadImage(buffer, true)
+}
@@ -0,0 +1,191 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"io"
+	"sort"
+	"testing"
+)
+
+/* fakeWidget is a text setting of fakeBackend */
+type fakeWidget struct {
+	backend *fakeBackend
+	name    string
+}
+
+func (w fakeWidget) Get() (interface{}, error) {
+	return w.backend.settings[w.name], nil
+}
+
+func (w fakeWidget) Set(input interface{}) error {
+	w.backend.attempts[w.name]++
+	if err := w.backend.setErrors[w.name]; err != nil {
+		return err
+	}
+	value := fmt.Sprint(input)
+	if options := w.backend.options[w.name]; len(options) != 0 {
+		found := false
+		for _, option := range options {
+			found = found || option == value
+		}
+		if !found {
+			return &gphoto2.GphotoError{Code: gphoto2.ErrorBadParameters}
+		}
+	}
+	w.backend.settings[w.name] = value
+	w.backend.sets = append(w.backend.sets, w.name+"="+value)
+	return nil
+}
+
+func (w fakeWidget) Options() ([]string, error) {
+	return w.backend.options[w.name], nil
+}
+
+func (w fakeWidget) Label() string            { return w.name }
+func (w fakeWidget) Type() gphoto2.WidgetType { return gphoto2.WidgetText }
+func (w fakeWidget) ReadOnly() bool           { return false }
+
+/* fakeBackend is an in-memory camera, settings missing in settings map are not supported by the body */
+type fakeBackend struct {
+	settings  map[string]string
+	options   map[string][]string
+	setErrors map[string]error
+	attempts  map[string]int
+	sets      []string
+	/* files on the card with their contents, noCard lists no storage at all */
+	files      map[string][]byte
+	noCard     bool
+	freeImages uint64
+	/* downloadErrors fail download of the named file after half of it is transferred */
+	downloadErrors map[string]error
+	/* empty counts downloads of the named file succeeding without transferring any data */
+	empty     map[string]int
+	downloads map[string]int
+	deleted   []string
+	/* capture adds files to the card when a frame is exposed, late card listings miss them */
+	capture []string
+	late    int
+	/* preview is jpeg data of liveview frames, nil when the body has no liveview */
+	preview []byte
+	writing []string
+	resets  int
+}
+
+/* newFakeBackend creates fake camera with the specified settings and no files */
+func newFakeBackend(settings map[string]string) *fakeBackend {
+	return &fakeBackend{
+		settings:       settings,
+		options:        make(map[string][]string),
+		setErrors:      make(map[string]error),
+		attempts:       make(map[string]int),
+		files:          make(map[string][]byte),
+		downloadErrors: make(map[string]error),
+		empty:          make(map[string]int),
+		downloads:      make(map[string]int),
+	}
+}
+
+/* file returns camera path of the named file on the card */
+func (b *fakeBackend) file(name string) gphoto2.CameraFilePath {
+	return gphoto2.CameraFilePath{Name: name, Folder: "/store_00010001/DCIM/100CANON"}
+}
+
+/* noSettings is a gphoto2 camera without any settings, binding finds no widget of any name */
+var noSettings = gphotoBackend{&gphoto2.Camera{Settings: &gphoto2.CameraWidget{}}}
+
+func (b *fakeBackend) GetSetting(name string) (Widget, error) {
+	/* missing settings are looked up by the binding, so sessions see what a real body without the setting reports */
+	if _, ok := b.settings[name]; !ok {
+		return noSettings.GetSetting(name)
+	}
+	return fakeWidget{backend: b, name: name}, nil
+}
+
+func (b *fakeBackend) ListFiles() ([]gphoto2.CameraStorageInfo, error) {
+	if b.noCard {
+		return nil, nil
+	}
+	if b.late > 0 {
+		b.late--
+	} else {
+		for _, name := range b.writing {
+			b.files[name] = []byte("frame " + name)
+		}
+		b.writing = nil
+	}
+	names := make([]string, 0, len(b.files))
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	directory := gphoto2.CameraFilePath{Name: "100CANON", Dir: true}
+	for _, name := range names {
+		directory.Children = append(directory.Children, b.file(name))
+	}
+	dcim := gphoto2.CameraFilePath{Name: "DCIM", Dir: true, Children: []gphoto2.CameraFilePath{directory}}
+	return []gphoto2.CameraStorageInfo{{Description: "SD", FreeImages: b.freeImages, Children: []gphoto2.CameraFilePath{dcim}}}, nil
+}
+
+func (b *fakeBackend) CaptureImage() (*gphoto2.CameraFilePath, error) {
+	b.writing = append(b.writing, b.capture...)
+	if b.late == 0 {
+		for _, name := range b.capture {
+			b.files[name] = []byte("frame " + name)
+		}
+		b.writing = nil
+	}
+	file := b.file(b.capture[len(b.capture)-1])
+	return &file, nil
+}
+
+func (b *fakeBackend) CapturePreview(buffer io.Writer) error {
+	if b.preview == nil {
+		return &gphoto2.GphotoError{Code: gphoto2.ErrorNotSupported}
+	}
+	_, err := buffer.Write(b.preview)
+	return err
+}
+
+func (b *fakeBackend) Download(file gphoto2.CameraFilePath, buffer io.Writer) error {
+	b.downloads[file.Name]++
+	data, ok := b.files[file.Name]
+	if !ok {
+		return &gphoto2.GphotoError{Code: gphoto2.ErrorFileNotFound}
+	}
+	if b.empty[file.Name] > 0 {
+		b.empty[file.Name]--
+		return nil
+	}
+	if err := b.downloadErrors[file.Name]; err != nil {
+		buffer.Write(data[:len(data)/2])
+		return err
+	}
+	_, err := buffer.Write(data)
+	return err
+}
+
+func (b *fakeBackend) DeleteFile(path *gphoto2.CameraFilePath) error {
+	delete(b.files, path.Name)
+	b.deleted = append(b.deleted, path.Name)
+	return nil
+}
+
+func (b *fakeBackend) Reset() error {
+	b.resets++
+	return nil
+}
+
+func (b *fakeBackend) Exit() error { return nil }
+func (b *fakeBackend) Free() error { return nil }
+
+func TestGetSettingMissing(t *testing.T) {
+	setting, err := noSettings.GetSetting("lensname")
+	if setting != nil {
+		t.Fatalf("GetSetting() = %v, want nil widget", setting)
+	}
+	if !errors.Is(err, ErrNotSupported) || !errors.Is(err, ErrBadValue) {
+		t.Errorf("GetSetting() = %v, want ErrNotSupported of class ErrBadValue", err)
+	}
+}
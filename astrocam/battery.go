@@ -0,0 +1,129 @@
+package astrocam
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* BatteryOrdinals maps descriptive battery levels to a coarse percentage scale */
+var BatteryOrdinals = map[string]float64{
+	"full":   100,
+	"high":   75,
+	"normal": 75,
+	"half":   50,
+	"low":    25,
+	"empty":  0,
+}
+
+/* ExternalPowerLevels are battery levels reported by bodies powered by an AC adapter or dummy battery */
+var ExternalPowerLevels = []string{"ac", "ac adapter", "ac power", "external", "external power", "dc in"}
+
+/* isExternalPower reports whether battery level indicates external power */
+func isExternalPower(level string) bool {
+	level = strings.ToLower(strings.TrimSpace(level))
+	for _, external := range ExternalPowerLevels {
+		if level == external {
+			return true
+		}
+	}
+	return false
+}
+
+/* detectExternalPower decides whether the camera runs on external power, either reported by the camera or forced with -ac-power for bodies reporting a fixed level */
+func (c *Camera) detectExternalPower() {
+	if c.ACPower || isExternalPower(c.Battery) {
+		c.externalPower = true
+		log.Printf("Camera is on external power (battery: %s), battery estimates disabled\n", c.Battery)
+	}
+}
+
+/* BatteryReading is a battery level reported by the camera at the specified time */
+type BatteryReading struct {
+	Time  time.Time
+	Level string
+}
+
+/* batteryPercent converts battery level to percent, descriptive levels are mapped to an ordinal scale */
+func batteryPercent(level string) (float64, bool) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if percent, err := strconv.ParseFloat(strings.TrimSuffix(level, "%"), 64); err == nil {
+		return percent, true
+	}
+	percent, ok := BatteryOrdinals[level]
+	return percent, ok
+}
+
+/* BatteryTrend describes battery discharge during a session */
+type BatteryTrend struct {
+	Start     float64
+	End       float64
+	PerHour   float64
+	Remaining time.Duration
+}
+
+/* BatteryTrend computes battery discharge trend from the first and last readable battery levels */
+func (s *SessionSummary) BatteryTrend() (trend BatteryTrend, ok bool) {
+	var first, last *BatteryReading
+	for i := range s.Battery {
+		if _, valid := batteryPercent(s.Battery[i].Level); !valid {
+			continue
+		}
+		if first == nil {
+			first = &s.Battery[i]
+		}
+		last = &s.Battery[i]
+	}
+	if first == nil || first == last {
+		return trend, false
+	}
+	trend.Start, _ = batteryPercent(first.Level)
+	trend.End, _ = batteryPercent(last.Level)
+	hours := last.Time.Sub(first.Time).Hours()
+	if hours <= 0 {
+		return trend, false
+	}
+	trend.PerHour = (trend.Start - trend.End) / hours
+	if trend.PerHour > 0 {
+		trend.Remaining = time.Duration(trend.End / trend.PerHour * float64(time.Hour))
+	}
+	return trend, true
+}
+
+/* readBattery reads current battery level and records it in the session summary */
+func (c *Camera) readBattery() {
+	c.Battery = c.infoConfig(BatteryLevel)
+	c.Summary.Battery = append(c.Summary.Battery, BatteryReading{Time: time.Now(), Level: c.Battery})
+	c.emit(Event{Type: EventBattery, Battery: c.Battery})
+	if percent, ok := batteryPercent(c.Battery); ok {
+		c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.Battery = percent })
+	}
+}
+
+/* estimateRemainingFrames estimates frames the current battery charge supports, using measured discharge per frame once available and configured cost until then; -1 means unknown */
+func (c *Camera) estimateRemainingFrames() int {
+	percent, ok := batteryPercent(c.Battery)
+	if !ok || c.externalPower {
+		return -1
+	}
+	cost := c.BatteryPerFrame
+	if trend, ok := c.Summary.BatteryTrend(); ok && c.Summary.Frames > 0 && trend.Start > trend.End {
+		cost = (trend.Start - trend.End) / float64(c.Summary.Frames)
+	}
+	if cost <= 0 {
+		return -1
+	}
+	return int(percent / cost)
+}
+
+/* checkBatteryFrames warns once when the battery is expected to run out before remaining frames are captured */
+func (c *Camera) checkBatteryFrames(remaining int) {
+	if c.batteryWarned || c.externalPower || remaining <= 0 {
+		return
+	}
+	if frames := c.estimateRemainingFrames(); frames >= 0 && frames < remaining {
+		log.Printf("Warning: %sbattery will run out before session completes, about %d of %d remaining frames, swap or charge it or use a dummy battery\n", c.prefix(), frames, remaining)
+		c.batteryWarned = true
+	}
+}
@@ -0,0 +1,84 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/* BenchShutter is the short shutter speed of benchmark captures */
+const BenchShutter = "1/100"
+
+/* BenchResult holds timings of a single benchmark capture */
+type BenchResult struct {
+	Capture  time.Duration
+	Download time.Duration
+	Bytes    int64
+}
+
+/* BenchReport holds timings of all benchmark captures */
+type BenchReport []BenchResult
+
+/* RunBench captures and downloads short frames measuring time from release to file available and download throughput */
+func (c *Camera) RunBench(iterations int) (report BenchReport, err error) {
+	c.checksums = make(map[string]string)
+	c.applyUSBTuning()
+	/* frames are short, file size matches configured image format */
+	if err := c.rememberConfig("shutterspeed", BenchShutter); err != nil {
+		return nil, fmt.Errorf("runBench(shutterspeed): %w", err)
+	}
+	if err := c.SetConfig("imageformat", c.ImageFormat); err != nil {
+		return nil, fmt.Errorf("runBench(imageformat): %w", err)
+	}
+	if err := c.SetConfig("capturetarget", "Memory card"); err != nil {
+		return nil, fmt.Errorf("runBench(capturetarget): %w", err)
+	}
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("astro-bench-%d", os.Getpid()))
+	defer os.Remove(path)
+	for i := 0; i < iterations; i++ {
+		released := time.Now()
+		file, err := c.camera.CaptureImage()
+		if err != nil {
+			return report, cameraError("runBench", err)
+		}
+		available := time.Now()
+		if err := c.downloadFile(context.Background(), *file, path); err != nil {
+			return report, fmt.Errorf("runBench: %w", err)
+		}
+		result := BenchResult{Capture: available.Sub(released), Download: time.Since(available)}
+		if info, err := os.Stat(path); err == nil {
+			result.Bytes = info.Size()
+		}
+		report = append(report, result)
+		fmt.Printf("  Frame %d: capture %v, download %v (%.1f MB)\n", i+1, result.Capture.Round(time.Millisecond), result.Download.Round(time.Millisecond), float64(result.Bytes)/(1<<20))
+		/* benchmark frames are not kept */
+		if err := c.camera.DeleteFile(file); err != nil {
+			return report, cameraError("runBench(delete)", err)
+		}
+	}
+	return report, nil
+}
+
+/* Print displays average benchmark timings */
+func (r BenchReport) Print() {
+	if len(r) == 0 {
+		return
+	}
+	var capture, download time.Duration
+	var bytes int64
+	for _, result := range r {
+		capture += result.Capture
+		download += result.Download
+		bytes += result.Bytes
+	}
+	count := time.Duration(len(r))
+	fmt.Printf("Benchmark:\n")
+	fmt.Printf("  Release to file available: %v\n", (capture / count).Round(time.Millisecond))
+	fmt.Printf("  Download:                  %v\n", (download / count).Round(time.Millisecond))
+	if download > 0 {
+		fmt.Printf("  Throughput:                %.1f MB/s\n", float64(bytes)/(1<<20)/download.Seconds())
+	}
+	fmt.Printf("  Per-frame overhead:        %v (add to -duration for the shortest -cadence)\n", ((capture + download) / count).Round(time.Millisecond))
+}
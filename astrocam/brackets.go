@@ -0,0 +1,61 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+/* checkBrackets validates exposure bracketing options */
+func (c *Camera) checkBrackets() error {
+	if len(c.Brackets) == 0 {
+		return nil
+	}
+	if !strings.EqualFold(c.Shutter, "bulb") {
+		return fmt.Errorf("Option -brackets requires -shutter=bulb")
+	}
+	if c.Sweep || c.DurationRamp.Enabled() {
+		return fmt.Errorf("Option -brackets can not be used with -sweep or -ramp-duration")
+	}
+	return nil
+}
+
+/* BracketTime returns exposure time of a single bracketed frame in seconds */
+func (c *Camera) BracketTime() int {
+	if len(c.Brackets) == 0 {
+		return c.Duration
+	}
+	total := 0
+	for _, duration := range c.Brackets {
+		total += duration
+	}
+	return total
+}
+
+/* bracketPath adds bracket duration to base name of path, so exposures of the same frame do not collide */
+func bracketPath(path string, duration int) string {
+	if duration == 0 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%ds%s", strings.TrimSuffix(path, ext), duration, ext)
+}
+
+/* captureBrackets captures frame once with every bracket duration, or once with configured duration without brackets */
+func (c *Camera) captureBrackets(ctx context.Context, frame int) error {
+	if len(c.Brackets) == 0 {
+		return c.captureFrame(ctx, frame)
+	}
+	duration := c.Duration
+	defer func() {
+		c.Duration, c.bracket = duration, 0
+	}()
+	for _, bracket := range c.Brackets {
+		c.Duration, c.bracket = bracket, bracket
+		if err := c.captureFrame(ctx, frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package astrocam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	/* ExposureHost is a bulb exposure timed by this program with remote release */
+	ExposureHost = "host"
+	/* ExposureInternal is a bulb exposure timed by the camera internal bulb timer */
+	ExposureInternal = "internal"
+	/* ExposureStatusPoll is the interval of polling exposure status setting */
+	ExposureStatusPoll = time.Millisecond * 100
+	/* ExposureStartTimeout is the time allowed for the camera to report that exposure started */
+	ExposureStartTimeout = time.Second * 2
+	/* ExposureEndTimeout is the time allowed for the camera to report that exposure ended after release */
+	ExposureEndTimeout = time.Second * 30
+	/* ExposureEndWait is the fixed wait after release on bodies without exposure status feedback */
+	ExposureEndWait = time.Millisecond * 100
+)
+
+/* InternalBulbSettings lists names of camera settings used by internal bulb timers */
+var InternalBulbSettings = []string{"bulbexposuretime", "bulbtimer"}
+
+/* ExposureModeSettings lists names of camera settings reporting exposure mode dial position */
+var ExposureModeSettings = []string{"autoexposuremode", "expprogram", "exposuremode"}
+
+/* setBulbShutter selects bulb shutter speed, bodies selecting bulb with the mode dial are only verified to be in bulb mode */
+func (c *Camera) setBulbShutter() error {
+	choice, err := c.findChoice("shutterspeed", "bulb")
+	if err == nil {
+		return c.SetConfig("shutterspeed", choice)
+	}
+	if !errors.Is(err, ErrBadValue) {
+		return err
+	}
+	for _, name := range ExposureModeSettings {
+		/* settings missing on the body or unreadable can not verify the mode */
+		mode, err := c.GetConfig(name)
+		if err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				log.Printf("Warning: unable to read %s: %v\n", name, err)
+			}
+			continue
+		}
+		if strings.EqualFold(mode, "bulb") || strings.EqualFold(mode, "b") {
+			return nil
+		}
+		return fmt.Errorf("setBulbShutter: bulb is not a shutter speed choice and the camera is in %s mode, turn the mode dial to Bulb (B)", mode)
+	}
+	log.Printf("Warning: bulb is not a shutter speed choice and the camera does not report its mode, make sure the mode dial is set to Bulb (B)\n")
+	return nil
+}
+
+/* detectInternalBulb looks up internal bulb timer setting of the camera */
+func (c *Camera) detectInternalBulb() string {
+	for _, name := range InternalBulbSettings {
+		_, err := c.camera.GetSetting(name)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: unable to read %s: %v\n", name, err)
+			continue
+		}
+		return name
+	}
+	return ""
+}
+
+/* exposeInternal captures a single frame timed by the camera internal bulb timer, record gets times the shutter opened and closed */
+func (c *Camera) exposeInternal(ctx context.Context, frame int, record *FrameRecord) error {
+	if err := c.SetConfig(c.internalBulb, strconv.Itoa(c.Duration)); err != nil {
+		return fmt.Errorf("exposeInternal(%s): %w", c.internalBulb, err)
+	}
+	/* capture blocks until the camera finishes exposure, display countdown meanwhile */
+	result := make(chan error, 1)
+	done := make(chan struct{})
+	var closed time.Time
+	go func() {
+		_, err := c.camera.CaptureImage()
+		closed = time.Now()
+		result <- err
+		close(done)
+	}()
+	/* countdown starts when self-timer expires and stops as soon as the camera finishes */
+	time.Sleep(c.selfTimerDelay())
+	record.Start = time.Now()
+	c.WaitExposure(ctx, frame, done)
+	/* the internal timer can not be stopped, so a cancelled exposure still runs to its end */
+	if ctx.Err() != nil {
+		fmt.Printf("\n%sWaiting for the camera to finish exposure of frame %d...\n", c.prefix(), frame)
+	}
+	if err := <-result; err != nil {
+		return cameraError("exposeInternal", err)
+	}
+	record.End = closed
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("exposeInternal: %w", err)
+	}
+	return nil
+}
+
+/* pollExposureStatus polls exposure status setting until running reports the expected state or context is done */
+func (c *Camera) pollExposureStatus(ctx context.Context, running bool) error {
+	ticker := time.NewTicker(ExposureStatusPoll)
+	defer ticker.Stop()
+	for {
+		value, err := c.GetConfig(c.ExposureStatus)
+		if err != nil {
+			return err
+		}
+		if (value != c.idleStatus) == running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+/* waitForExposureStart confirms that exposure is running before it is timed, so release is never sent too early */
+func (c *Camera) waitForExposureStart(ctx context.Context) error {
+	if c.idleStatus == "" {
+		return nil
+	}
+	if err := c.pollExposureStatus(ctx, true); err != nil {
+		return fmt.Errorf("waitForExposureStart: %w", err)
+	}
+	return nil
+}
+
+/* waitForExposureEnd waits until camera reports that exposure ended, falling back to a fixed wait without status feedback */
+func (c *Camera) waitForExposureEnd(ctx context.Context) error {
+	if c.idleStatus == "" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ExposureEndWait):
+			return nil
+		}
+	}
+	if err := c.pollExposureStatus(ctx, false); err != nil {
+		return fmt.Errorf("waitForExposureEnd: %w", err)
+	}
+	return nil
+}
+
+/* exposeHost captures a single frame timed by remote release button states, shutter is released even when context is cancelled; record gets times the shutter opened and closed */
+func (c *Camera) exposeHost(ctx context.Context, frame int, record *FrameRecord) error {
+	/* idle value of exposure status setting, empty when the body gives no feedback */
+	c.idleStatus = ""
+	if c.ExposureStatus != "" {
+		if value, err := c.GetConfig(c.ExposureStatus); err == nil {
+			c.idleStatus = value
+		} else {
+			log.Printf("Warning: exposure status not available, using timed release: %v\n", err)
+		}
+	}
+	/* two-stage release, exposure is timed from the second stage so its duration is not affected */
+	if err := c.prefire(); err != nil {
+		return err
+	}
+	/* start frame exposure */
+	if err := c.setRelease(ReleaseImmediate); err != nil {
+		return err
+	}
+	pressed := time.Now()
+	/* self-timer delays exposure start, without status feedback the delay is simply waited out */
+	if c.SelfTimer > 0 && c.idleStatus == "" {
+		time.Sleep(c.selfTimerDelay())
+	}
+	start, cancel := context.WithTimeout(ctx, ExposureStartTimeout+c.selfTimerDelay())
+	err := c.waitForExposureStart(start)
+	cancel()
+	/* prefire and self-timer are not a part of the exposure */
+	record.Start = time.Now()
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Warning: %v, timing exposure from release command\n", err)
+		record.Start = pressed.Add(c.selfTimerDelay())
+	}
+	/* wait for the specified duration */
+	c.WaitExposure(ctx, frame, nil)
+
+	/* some bodies miss the release if it follows immediate too closely */
+	if hold := c.ReleaseHold - time.Since(pressed); hold > 0 {
+		time.Sleep(hold)
+	}
+	/* stop frame exposure */
+	if err := c.Release(); err != nil {
+		return err
+	}
+	record.End = time.Now()
+	/* exposure end is awaited regardless of cancellation, so the frame is complete on the card */
+	end, cancel := context.WithTimeout(context.Background(), ExposureEndTimeout)
+	defer cancel()
+	if err := c.waitForExposureEnd(end); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("exposeHost: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package astrocam
+
+import (
+	"testing"
+)
+
+func TestDetectInternalBulb(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings []string
+		want     string
+	}{
+		{"no timer", nil, ""},
+		{"bulb timer", []string{"bulbtimer"}, "bulbtimer"},
+		{"bulb exposure time", []string{"bulbexposuretime"}, "bulbexposuretime"},
+		{"both", []string{"bulbtimer", "bulbexposuretime"}, "bulbexposuretime"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := map[string]string{"iso": "800"}
+			for _, name := range tt.settings {
+				settings[name] = "0"
+			}
+			c, _ := newFakeCamera(t, settings)
+			if got := c.detectInternalBulb(); got != tt.want {
+				t.Errorf("detectInternalBulb() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
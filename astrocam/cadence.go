@@ -0,0 +1,29 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+/* waitCadence blocks until scheduled start of the next frame, which is session start plus a whole number of cadence intervals */
+func (c *Camera) waitCadence(ctx context.Context) error {
+	/* absolute schedule prevents exposure and download jitter from accumulating */
+	due := c.Summary.Start.Add(c.Cadence * time.Duration(c.cadenceFrames))
+	c.cadenceFrames++
+	if late := time.Since(due); late > 0 {
+		if c.cadenceFrames == 1 {
+			return nil
+		}
+		if c.CadenceStrict {
+			return fmt.Errorf("waitCadence: frame %d started %v late, previous frame overran cadence of %v", c.cadenceFrames, late.Round(time.Millisecond), c.Cadence)
+		}
+		log.Printf("Warning: %sframe %d started %v late, previous frame overran cadence of %v\n", c.prefix(), c.cadenceFrames, late.Round(time.Millisecond), c.Cadence)
+		return nil
+	}
+	if err := c.wait(ctx, time.Until(due)); err != nil {
+		return fmt.Errorf("waitCadence: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package astrocam
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jonmol/gphoto2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/* newCaptureCamera creates session exposing frames with camera internal bulb timer of fake backend */
+func newCaptureCamera(t *testing.T) (*Camera, *fakeBackend) {
+	c, backend := newFakeCamera(t, map[string]string{"iso": "800", "bulbtimer": "0"})
+	c.internalBulb = "bulbtimer"
+	c.Duration = 1
+	c.DeleteDelay = 0
+	c.NoReset = true
+	return c, backend
+}
+
+/* readSidecar returns metadata written next to the first file of the frame */
+func readSidecar(t *testing.T, record FrameRecord) FrameMetadata {
+	data, err := os.ReadFile(sidecarPath(filepath.Join(record.Dir, record.Files[0])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata FrameMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	return metadata
+}
+
+func TestCaptureFailedISOSet(t *testing.T) {
+	c, backend := newCaptureCamera(t)
+	c.Sidecar = true
+	logPath := filepath.Join(t.TempDir(), "frames.log")
+	frameLog, err := NewFrameLog(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer frameLog.fh.Close()
+	c.FrameLog = frameLog
+	backend.capture = []string{"IMG_0001.CR2"}
+	if err := c.CaptureBulb(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	/* iso change mid-session is rejected by the body, which keeps exposing at the previous iso */
+	backend.setErrors["iso"] = &gphoto2.GphotoError{Code: gphoto2.ErrorBadParameters}
+	if err := c.SetConfig("iso", "1600"); err == nil {
+		t.Fatal("SetConfig(iso) = nil, want error")
+	}
+	c.ISO = "1600"
+	backend.capture = []string{"IMG_0002.CR2"}
+	if err := c.CaptureBulb(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Summary.Records) != 2 {
+		t.Fatalf("recorded %d frames, want 2", len(c.Summary.Records))
+	}
+	for _, record := range c.Summary.Records {
+		if record.ISO != "800" {
+			t.Errorf("frame %d recorded iso %s, want 800 reported by camera", record.Frame, record.ISO)
+		}
+		if metadata := readSidecar(t, record); metadata.ISO != "800" {
+			t.Errorf("frame %d sidecar iso %s, want 800 reported by camera", record.Frame, metadata.ISO)
+		}
+	}
+	/* frame log agrees with the sidecar */
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry FrameLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.ISO != "800" {
+			t.Errorf("frame %d logged iso %s, want 800 reported by camera", entry.Frame, entry.ISO)
+		}
+	}
+}
+
+func TestCaptureDarks(t *testing.T) {
+	c, backend := newCaptureCamera(t)
+	c.Kind = "darks"
+	/* frame is written to the card only after the first two listings */
+	backend.capture = []string{"IMG_0001.CR2"}
+	backend.late = 2
+	start := time.Now()
+	if err := c.CaptureBulb(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= DefaultTiming.PostExposureWait {
+		t.Errorf("dark frame took %v, want less than default post exposure wait %v", elapsed, DefaultTiming.PostExposureWait)
+	}
+	if len(c.Summary.Records) != 1 || len(c.Summary.Records[0].Files) != 1 {
+		t.Fatalf("recorded %+v, want one frame", c.Summary.Records)
+	}
+	data, err := os.ReadFile(filepath.Join(c.Target, "darks", "IMG_0001.CR2"))
+	if err != nil || string(data) != "frame IMG_0001.CR2" {
+		t.Errorf("downloaded %q (%v), want frame IMG_0001.CR2", data, err)
+	}
+	if len(backend.deleted) != 1 {
+		t.Errorf("deleted %v, want downloaded frame removed from the card", backend.deleted)
+	}
+}
+
+func TestCaptureHostExposureTime(t *testing.T) {
+	c, backend := newFakeCamera(t, map[string]string{"iso": "800", EosRemoteRelease: "None"})
+	c.Duration, c.DeleteDelay, c.NoReset = 1, 0, true
+	c.MirrorPrefire = time.Millisecond * 500
+	/* host timed exposure does not capture through the backend, the frame appears on the card at the next listing */
+	backend.writing = []string{"IMG_0001.CR2"}
+	requested := time.Now()
+	if err := c.CaptureBulb(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Summary.Records) != 1 {
+		t.Fatalf("recorded %d frames, want 1", len(c.Summary.Records))
+	}
+	record := c.Summary.Records[0]
+	if record.Start.Sub(requested) < c.MirrorPrefire {
+		t.Errorf("exposure started %v after request, want after %v mirror prefire", record.Start.Sub(requested), c.MirrorPrefire)
+	}
+	/* exposure time does not include prefire, only the host timed exposure and release */
+	if drift := record.Actual - record.Requested; drift < 0 || drift > c.MirrorPrefire/2 {
+		t.Errorf("exposure %v of requested %v, drift %v", record.Actual, record.Requested, drift)
+	}
+}
@@ -0,0 +1,122 @@
+package astrocam
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+/* ChecksumFileName is the name of sha256 manifest of downloaded frames in target directory */
+const ChecksumFileName = "checksums.txt"
+
+/* fileChecksum computes sha256 checksum of a local file */
+func fileChecksum(path string) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, fh); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+/* checksumLock serializes manifest appends of capture and background dng conversion */
+var checksumLock sync.Mutex
+
+/* takeChecksum returns checksum of a downloaded frame computed during download, or empty string if unknown */
+func (c *Camera) takeChecksum(path string) string {
+	sum := c.checksums[path]
+	delete(c.checksums, path)
+	return sum
+}
+
+/* appendChecksum appends checksum of a downloaded frame to the manifest in sha256sum format, reusing checksum computed during download */
+func (c *Camera) appendChecksum(path string) error {
+	return c.writeChecksum(path, c.takeChecksum(path))
+}
+
+/* writeChecksum appends the specified checksum of a file to the manifest, checksum is computed when empty */
+func (c *Camera) writeChecksum(path, sum string) error {
+	if sum == "" {
+		var err error
+		if sum, err = fileChecksum(path); err != nil {
+			return fmt.Errorf("appendChecksum(%s): %w", path, err)
+		}
+	}
+	checksumLock.Lock()
+	defer checksumLock.Unlock()
+	name, err := filepath.Rel(c.Target, path)
+	if err != nil {
+		return fmt.Errorf("appendChecksum(%s): %w", path, err)
+	}
+	fh, err := os.OpenFile(filepath.Join(c.Target, ChecksumFileName), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("appendChecksum(%s): %w", path, err)
+	}
+	if _, err := fmt.Fprintf(fh, "%s  %s\n", sum, filepath.ToSlash(name)); err != nil {
+		fh.Close()
+		return fmt.Errorf("appendChecksum(%s): %w", path, err)
+	}
+	return fh.Close()
+}
+
+/* loadChecksums returns set of checksums listed in target manifest, missing manifest lists none */
+func loadChecksums(target string) (map[string]bool, error) {
+	sums := make(map[string]bool)
+	fh, err := os.Open(filepath.Join(target, ChecksumFileName))
+	if os.IsNotExist(err) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loadChecksums: %w", err)
+	}
+	defer fh.Close()
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		if fields := strings.SplitN(scanner.Text(), "  ", 2); len(fields) == 2 {
+			sums[fields[0]] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("loadChecksums: %w", err)
+	}
+	return sums, nil
+}
+
+/* VerifyChecksums checks all files listed in target manifest and returns number of verified files and list of failures */
+func VerifyChecksums(target string) (verified int, failures []string, err error) {
+	fh, err := os.Open(filepath.Join(target, ChecksumFileName))
+	if err != nil {
+		return 0, nil, fmt.Errorf("verifyChecksums: %w", err)
+	}
+	defer fh.Close()
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		sum, err := fileChecksum(filepath.Join(target, filepath.FromSlash(fields[1])))
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", fields[1], err))
+		case sum != fields[0]:
+			failures = append(failures, fmt.Sprintf("%s: checksum mismatch", fields[1]))
+		default:
+			verified++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return verified, failures, fmt.Errorf("verifyChecksums: %w", err)
+	}
+	return verified, failures, nil
+}
@@ -0,0 +1,84 @@
+package astrocam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+)
+
+/* CloudDetector reports whether sky is clear enough to capture lights */
+type CloudDetector interface {
+	IsClear() (bool, error)
+}
+
+/* CommandCloudDetector runs a shell command which exits with status 0 for clear sky and 1 for clouds */
+type CommandCloudDetector struct {
+	Command string
+}
+
+/* IsClear runs detector command, exit statuses other than 0 and 1 are reported as errors */
+func (d CommandCloudDetector) IsClear() (bool, error) {
+	cmd := exec.Command("sh", "-c", d.Command)
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("CommandCloudDetector(%s): %w", d.Command, err)
+}
+
+/* PauseEvent records a capture pause, e.g. caused by clouds */
+type PauseEvent struct {
+	Start  time.Time
+	End    time.Time
+	Frame  int
+	Reason string
+}
+
+/* waitClearSky pauses capture before the specified frame until sky clears, detector failures do not stop capture */
+func (c *Camera) waitClearSky(ctx context.Context, frame int) error {
+	clear, err := c.Clouds.IsClear()
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+		return nil
+	}
+	if clear {
+		return nil
+	}
+	pause := PauseEvent{Start: time.Now(), Frame: frame, Reason: "clouds"}
+	fmt.Printf("\n%sSky is cloudy, pausing before frame %d\n", c.prefix(), frame)
+	for !clear {
+		if c.CloudTimeout > 0 && time.Since(pause.Start) >= c.CloudTimeout {
+			pause.End = time.Now()
+			c.Summary.Pauses = append(c.Summary.Pauses, pause)
+			return fmt.Errorf("waitClearSky: sky did not clear within %v", c.CloudTimeout)
+		}
+		if err := c.wait(ctx, c.CloudInterval); err != nil {
+			pause.End = time.Now()
+			c.Summary.Pauses = append(c.Summary.Pauses, pause)
+			return fmt.Errorf("waitClearSky: %w", err)
+		}
+		/* stop request ends the pause, capture loop then stops before the next frame */
+		if c.Stop.Stopped() {
+			pause.End = time.Now()
+			c.Summary.Pauses = append(c.Summary.Pauses, pause)
+			return nil
+		}
+		if clear, err = c.Clouds.IsClear(); err != nil {
+			log.Printf("Warning: %v\n", err)
+			clear = true
+		}
+	}
+	pause.End = time.Now()
+	c.Summary.Pauses = append(c.Summary.Pauses, pause)
+	fmt.Printf("%sSky is clear, resuming after %v\n", c.prefix(), pause.End.Sub(pause.Start).Round(time.Second))
+	return nil
+}
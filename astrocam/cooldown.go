@@ -0,0 +1,49 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+/* CooldownKeepAlive is the interval of camera reads keeping the connection alive during cooldown */
+const CooldownKeepAlive = time.Second * 30
+
+/* TemperatureSettings lists names of camera settings reporting sensor or body temperature, few bodies provide any */
+var TemperatureSettings = []string{"sensortemperature", "cameratemperature", "temperature"}
+
+/* readTemperature reads sensor or body temperature if the camera reports it */
+func (c *Camera) readTemperature() (string, bool) {
+	for _, name := range TemperatureSettings {
+		if value, err := c.GetConfig(name); err == nil {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+/* cooldown pauses capture to let the sensor cool down, camera is polled meanwhile so it does not go to sleep; cancelled context or stop request ends the pause early */
+func (c *Camera) cooldown(ctx context.Context, frame int) {
+	pause := PauseEvent{Start: time.Now(), Frame: frame + 1, Reason: "cooldown"}
+	before, ok := c.readTemperature()
+	fmt.Printf("\n%sCooling down for %v after frame %d\n", c.prefix(), c.CooldownDuration, frame)
+	for remaining := c.CooldownDuration; remaining > 0; remaining -= CooldownKeepAlive {
+		wait := remaining
+		if wait > CooldownKeepAlive {
+			wait = CooldownKeepAlive
+		}
+		if c.wait(ctx, wait) != nil || c.Stop.Stopped() {
+			break
+		}
+		c.readBattery()
+	}
+	pause.End = time.Now()
+	c.Summary.Pauses = append(c.Summary.Pauses, pause)
+	/* confirm the camera actually cooled down where temperature is reported */
+	if ok {
+		if after, ok := c.readTemperature(); ok {
+			log.Printf("%sTemperature before cooldown %s, after cooldown %s\n", c.prefix(), before, after)
+		}
+	}
+}
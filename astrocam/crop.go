@@ -0,0 +1,106 @@
+package astrocam
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/* CropPreviewName is the file name of the cropped preview of the latest jpeg frame in kind directory */
+const CropPreviewName = "crop-preview.jpg"
+
+/* Crop is the intended framing of the target in frame pixels, recorded for reproducibility and never applied to raw frames */
+type Crop struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+/* String formats crop as x,y,w,h */
+func (r *Crop) String() string {
+	if !r.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%d,%d,%d,%d", r.X, r.Y, r.Width, r.Height)
+}
+
+/* Set parses crop in x,y,w,h format */
+func (r *Crop) Set(value string) error {
+	fields := strings.Split(value, ",")
+	if len(fields) != 4 {
+		return fmt.Errorf("bad crop %q (must be x,y,w,h)", value)
+	}
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || v < 0 {
+			return fmt.Errorf("bad crop %q (must be non-negative integers)", value)
+		}
+		values[i] = v
+	}
+	if values[2] == 0 || values[3] == 0 {
+		return fmt.Errorf("bad crop %q (width and height must be positive)", value)
+	}
+	*r = Crop{X: values[0], Y: values[1], Width: values[2], Height: values[3]}
+	return nil
+}
+
+/* Enabled reports whether crop was set */
+func (r *Crop) Enabled() bool {
+	return r.Width > 0 && r.Height > 0
+}
+
+/* Rect returns crop as image rectangle */
+func (r *Crop) Rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+/* crop returns configured crop or nil when not set, for optional metadata fields */
+func (c *Camera) crop() *Crop {
+	if !c.Crop.Enabled() {
+		return nil
+	}
+	crop := c.Crop
+	return &crop
+}
+
+/* cropPreview returns part of image inside rect, clipped to image bounds */
+func cropPreview(img image.Image, rect image.Rectangle) image.Image {
+	rect = rect.Add(img.Bounds().Min).Intersect(img.Bounds())
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	return img
+}
+
+/* writeCropPreview writes cropped copy of downloaded jpeg frame to kind directory */
+func (c *Camera) writeCropPreview(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("writeCropPreview: %w", err)
+	}
+	img, err := jpeg.Decode(fh)
+	fh.Close()
+	if err != nil {
+		return fmt.Errorf("writeCropPreview(%s): %w", path, err)
+	}
+	cropped := cropPreview(img, c.Crop.Rect())
+	if cropped.Bounds().Empty() {
+		return fmt.Errorf("writeCropPreview: crop %s is outside of %dx%d frame", c.Crop.String(), img.Bounds().Dx(), img.Bounds().Dy())
+	}
+	out, err := os.Create(c.framePath(CropPreviewName))
+	if err != nil {
+		return fmt.Errorf("writeCropPreview: %w", err)
+	}
+	if err := jpeg.Encode(out, cropped, &jpeg.Options{Quality: 90}); err != nil {
+		out.Close()
+		return fmt.Errorf("writeCropPreview: %w", err)
+	}
+	return out.Close()
+}
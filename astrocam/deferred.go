@@ -0,0 +1,42 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+/* deferFiles records new camera files of a frame whose download is deferred until the end of session */
+func (c *Camera) deferFiles(record *FrameRecord, files CameraFiles) {
+	if len(files) == 0 {
+		log.Printf("Warning: %sno new file found on the camera after frame %d\n", c.prefix(), record.Frame)
+	}
+	for _, file := range files {
+		record.Files = append(record.Files, file.Name)
+		c.Summary.Deferred = append(c.Summary.Deferred, file.Name)
+	}
+	/* deferred files are known to the session so they are not detected as new again */
+	c.Files = append(c.Files, files...)
+	record.Actual = record.End.Sub(record.Start)
+	c.Summary.Records = append(c.Summary.Records, *record)
+	c.frameDone(*record)
+	c.logFrame(*record)
+}
+
+/* downloadDeferred downloads all frames left on the card during the session using its saved state */
+func (c *Camera) downloadDeferred(ctx context.Context) error {
+	if len(c.Summary.Deferred) == 0 {
+		return nil
+	}
+	fmt.Printf("%sDownloading %d deferred files...\n", c.prefix(), len(c.Summary.Deferred))
+	state, err := c.loadState()
+	if err != nil {
+		return fmt.Errorf("downloadDeferred: %w", err)
+	}
+	names, err := c.downloadUnknown(ctx, state)
+	if err != nil {
+		return fmt.Errorf("downloadDeferred: %w (use -recover to retry)", err)
+	}
+	fmt.Printf("%sDownloaded %d deferred files to %s.\n", c.prefix(), len(names), state.Dir)
+	return nil
+}
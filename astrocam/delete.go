@@ -0,0 +1,56 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	/* DeleteDownloaded removes only new files which were successfully downloaded and verified */
+	DeleteDownloaded = "downloaded"
+	/* DeleteAllNew removes every file that appeared during the session, including failed downloads (data loss risk) */
+	DeleteAllNew = "all-new"
+	/* DeleteNone keeps all files on the camera */
+	DeleteNone = "none"
+)
+
+/* CheckDeletePolicy validates delete policy and reconciles it with the keep option */
+func (c *Camera) CheckDeletePolicy() error {
+	switch c.DeletePolicy {
+	case DeleteDownloaded, DeleteAllNew, DeleteNone:
+	default:
+		return fmt.Errorf("Bad 'delete-policy' option: %s (must be one of '%s', '%s' or '%s')", c.DeletePolicy, DeleteDownloaded, DeleteAllNew, DeleteNone)
+	}
+	if c.Keep {
+		if c.Explicit["delete-policy"] && c.DeletePolicy != DeleteNone {
+			return fmt.Errorf("Options -keep and -delete-policy=%s are mutually exclusive", c.DeletePolicy)
+		}
+		c.DeletePolicy = DeleteNone
+	}
+	return nil
+}
+
+/* deleteNewFiles removes new files from the camera according to delete policy, kept files are added to known files */
+func (c *Camera) deleteNewFiles(files CameraFiles, downloaded map[string]bool) error {
+	delayed := false
+	for i := range files {
+		remove := c.DeletePolicy == DeleteAllNew || (c.DeletePolicy == DeleteDownloaded && downloaded[files[i].Name])
+		if !remove {
+			c.Files = append(c.Files, files[i])
+			continue
+		}
+		if !downloaded[files[i].Name] {
+			log.Printf("Warning: removing %s from the camera although it was not downloaded\n", files[i].Name)
+		}
+		/* let the camera finish flushing to the card before the first delete */
+		if !delayed {
+			time.Sleep(c.DeleteDelay)
+			delayed = true
+		}
+		if err := c.camera.DeleteFile(&files[i]); err != nil {
+			return cameraError("deleteNewFiles("+files[i].Name+")", err)
+		}
+	}
+	return nil
+}
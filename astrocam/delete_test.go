@@ -0,0 +1,79 @@
+package astrocam
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDeleteNewFiles(t *testing.T) {
+	tests := []struct {
+		policy  string
+		deleted []string
+		kept    []string
+	}{
+		{DeleteDownloaded, []string{"IMG_0001.CR2", "IMG_0001.JPG"}, []string{"IMG_0002.CR2"}},
+		{DeleteAllNew, []string{"IMG_0001.CR2", "IMG_0001.JPG", "IMG_0002.CR2"}, nil},
+		{DeleteNone, nil, []string{"IMG_0001.CR2", "IMG_0001.JPG", "IMG_0002.CR2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			c, backend := newFakeCamera(t, nil)
+			c.DeletePolicy, c.DeleteDelay = tt.policy, 0
+			files := CameraFiles{}
+			for _, name := range []string{"IMG_0001.CR2", "IMG_0001.JPG", "IMG_0002.CR2"} {
+				backend.files[name] = []byte(name)
+				files = append(files, backend.file(name))
+			}
+			/* the last file failed to download */
+			downloaded := map[string]bool{"IMG_0001.CR2": true, "IMG_0001.JPG": true}
+			if err := c.deleteNewFiles(files, downloaded); err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(backend.deleted)
+			if !reflect.DeepEqual(backend.deleted, tt.deleted) {
+				t.Errorf("deleted %v, want %v", backend.deleted, tt.deleted)
+			}
+			/* kept files are known to the session so they are not detected as new again */
+			kept := []string(nil)
+			for _, file := range c.Files {
+				kept = append(kept, file.Name)
+			}
+			if !reflect.DeepEqual(kept, tt.kept) {
+				t.Errorf("kept %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
+
+func TestCheckDeletePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		keep     bool
+		explicit bool
+		want     string
+		wantErr  bool
+	}{
+		{"default", DeleteDownloaded, false, false, DeleteDownloaded, false},
+		{"all new", DeleteAllNew, false, true, DeleteAllNew, false},
+		{"unknown", "everything", false, true, "", true},
+		{"keep", DeleteDownloaded, true, false, DeleteNone, false},
+		{"keep and none", DeleteNone, true, true, DeleteNone, false},
+		{"keep and all new", DeleteAllNew, true, true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			c.DeletePolicy, c.Keep = tt.policy, tt.keep
+			c.Explicit["delete-policy"] = tt.explicit
+			err := c.CheckDeletePolicy()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckDeletePolicy() = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && c.DeletePolicy != tt.want {
+				t.Errorf("delete policy %q, want %q", c.DeletePolicy, tt.want)
+			}
+		})
+	}
+}
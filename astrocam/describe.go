@@ -0,0 +1,52 @@
+package astrocam
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* SettingDescription describes a single camera setting */
+type SettingDescription struct {
+	Name     string
+	Label    string
+	Type     string
+	Value    string
+	ReadOnly bool
+	Choices  []string
+}
+
+/* DescribeSetting reads current value, type, access and enumerated choices of camera setting */
+func (c *Camera) DescribeSetting(name string) (SettingDescription, error) {
+	setting, err := c.camera.GetSetting(name)
+	if err != nil {
+		return SettingDescription{}, cameraError("describeSetting("+name+")", err)
+	}
+	description := SettingDescription{
+		Name:     name,
+		Label:    setting.Label(),
+		Type:     string(setting.Type()),
+		ReadOnly: setting.ReadOnly(),
+	}
+	if value, err := setting.Get(); err == nil {
+		description.Value = fmt.Sprint(value)
+	}
+	/* text, range and date settings have no enumerated choices */
+	if choices, err := setting.Options(); err == nil {
+		description.Choices = choices
+	}
+	return description, nil
+}
+
+/* Print displays setting description */
+func (d SettingDescription) Print() {
+	access := "read/write"
+	if d.ReadOnly {
+		access = "read only"
+	}
+	fmt.Printf("%s (%s):\n", d.Name, d.Label)
+	fmt.Printf("  Type:    %s, %s\n", d.Type, access)
+	fmt.Printf("  Value:   %s\n", d.Value)
+	if len(d.Choices) != 0 {
+		fmt.Printf("  Choices: %s\n", strings.Join(d.Choices, " | "))
+	}
+}
@@ -0,0 +1,18 @@
+package astrocam
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+/* Dither runs dither command moving the mount a few pixels so fixed pattern noise does not stack up in the same place */
+func (c *Camera) Dither() error {
+	cmd := exec.Command("sh", "-c", c.DitherCmd)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Dither: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	/* DNGQueueSize is the number of frames waiting for conversion before capture blocks */
+	DNGQueueSize = 64
+	/* CommandNotFound is the exit status of shell when converter executable does not exist */
+	CommandNotFound = 127
+)
+
+/* RawExtensions lists extensions of camera raw files converted to dng */
+var RawExtensions = []string{".cr2", ".cr3", ".crw", ".nef", ".nrw", ".arw", ".raf", ".orf", ".rw2", ".pef"}
+
+/* isRaw returns true if file name has a camera raw extension */
+func isRaw(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, raw := range RawExtensions {
+		if ext == raw {
+			return true
+		}
+	}
+	return false
+}
+
+/* dngJob is a downloaded raw frame waiting for conversion together with its download checksum */
+type dngJob struct {
+	path string
+	sum  string
+}
+
+/* DNGConverter converts downloaded raw frames to dng in background so that conversion does not delay next exposure */
+type DNGConverter struct {
+	Command  string
+	KeepRaw  bool
+	camera   *Camera
+	disabled bool
+	queue    chan dngJob
+	done     chan struct{}
+}
+
+/* NewDNGConverter creates converter running shell command with source raw as $1 and destination dng as $2 */
+func NewDNGConverter(c *Camera, command string, keepRaw bool) *DNGConverter {
+	d := &DNGConverter{
+		Command: command,
+		KeepRaw: keepRaw,
+		camera:  c,
+		queue:   make(chan dngJob, DNGQueueSize),
+		done:    make(chan struct{}),
+	}
+	go d.loop()
+	return d
+}
+
+/* convertToDNG converts raw file to dng next to it and returns dng path, existing dng files are never overwritten */
+func (d *DNGConverter) convertToDNG(src string) (string, error) {
+	dst := uniquePath(strings.TrimSuffix(src, filepath.Ext(src)) + ".dng")
+	cmd := exec.Command("sh", "-c", d.Command, "sh", src, dst)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(dst)
+		return "", fmt.Errorf("convertToDNG(%s): %w", src, err)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		return "", fmt.Errorf("convertToDNG(%s): %w", src, err)
+	}
+	return dst, nil
+}
+
+/* loop converts queued frames, the original raw is kept whenever conversion fails */
+func (d *DNGConverter) loop() {
+	defer close(d.done)
+	for job := range d.queue {
+		if !d.disabled {
+			dst, err := d.convertToDNG(job.path)
+			if err == nil {
+				if err := d.camera.writeChecksum(dst, ""); err != nil {
+					log.Printf("Warning: %v\n", err)
+				}
+				if d.KeepRaw {
+					if err := d.camera.writeChecksum(job.path, job.sum); err != nil {
+						log.Printf("Warning: %v\n", err)
+					}
+				} else if err := os.Remove(job.path); err != nil {
+					log.Printf("Warning: %v\n", err)
+				}
+				continue
+			}
+			log.Printf("Warning: %v, keeping original raw\n", err)
+			/* converter is missing, keep all remaining frames as raw */
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == CommandNotFound {
+				log.Printf("Warning: dng converter not found, disabling conversion\n")
+				d.disabled = true
+			}
+		}
+		if err := d.camera.writeChecksum(job.path, job.sum); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+	}
+}
+
+/* Queue schedules conversion of a downloaded raw frame */
+func (d *DNGConverter) Queue(path, sum string) {
+	d.queue <- dngJob{path: path, sum: sum}
+}
+
+/* Wait waits for all queued conversions to finish */
+func (d *DNGConverter) Wait() {
+	if d == nil {
+		return
+	}
+	close(d.queue)
+	<-d.done
+}
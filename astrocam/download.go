@@ -0,0 +1,104 @@
+package astrocam
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+/* DownloadRetryDelay is the pause between two download attempts */
+const DownloadRetryDelay = time.Second * 2
+
+/* ErrFrameTooLarge is returned when camera file exceeds maximal frame size */
+var ErrFrameTooLarge = errors.New("frame exceeds maximal frame size")
+
+/* countingWriter counts bytes written to the underlying writer, refusing to write more than limit bytes if set */
+type countingWriter struct {
+	w     io.Writer
+	n     int64
+	limit int64
+}
+
+/* Write writes data to the underlying writer and counts written bytes */
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.limit > 0 && cw.n+int64(len(p)) > cw.limit {
+		return 0, fmt.Errorf("%w (%d bytes, limit %d bytes)", ErrFrameTooLarge, cw.n+int64(len(p)), cw.limit)
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+/* downloadOnce downloads camera file to a freshly created local file and verifies its size */
+func (c *Camera) downloadOnce(file gphoto2.CameraFilePath, path string) (int64, error) {
+	fh, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	/* shared network links are throttled */
+	var out io.Writer = fh
+	if rate := c.downloadRate(path); rate > 0 {
+		out = newRateLimitedWriter(fh, rate)
+	}
+	/* checksum is computed while the file is written to avoid reading it back */
+	hash := sha256.New()
+	/* the binding reports no file size before transfer, so size is enforced while writing */
+	counter := &countingWriter{w: io.MultiWriter(out, hash), limit: c.MaxFrameSize << 20}
+	if err := c.camera.Download(file, counter); err != nil {
+		fh.Close()
+		return counter.n, cameraError("DownloadImage", err)
+	}
+	if err := fh.Close(); err != nil {
+		return counter.n, err
+	}
+	/* the binding does not report file size, so verify local size against transferred bytes */
+	info, err := os.Stat(path)
+	if err != nil {
+		return counter.n, err
+	}
+	/* some drivers report success without transferring any data, the file is still on the card so download is retried */
+	if counter.n == 0 {
+		return 0, &CameraError{Op: "downloadOnce", Class: ErrTransient, Err: fmt.Errorf("empty file transferred")}
+	}
+	if info.Size() != counter.n {
+		return counter.n, &CameraError{Op: "downloadOnce", Class: ErrTransient, Err: fmt.Errorf("size mismatch: %d bytes on disk, %d bytes transferred", info.Size(), counter.n)}
+	}
+	c.checksums[path] = hex.EncodeToString(hash.Sum(nil))
+	return counter.n, nil
+}
+
+/* downloadFile downloads camera file to local path, partial files are removed and download retried from scratch until context is cancelled */
+func (c *Camera) downloadFile(ctx context.Context, file gphoto2.CameraFilePath, path string) (err error) {
+	c.emit(Event{Type: EventDownloadStart, File: path})
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt != 0 {
+			c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.Retries++ })
+			if e := sleepContext(ctx, DownloadRetryDelay); e != nil {
+				err = e
+				break
+			}
+		}
+		var n int64
+		if n, err = c.downloadOnce(file, path); err == nil {
+			c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.DownloadBytes += n })
+			c.emit(Event{Type: EventDownloadEnd, File: path, Bytes: n})
+			return nil
+		}
+		os.Remove(path)
+		log.Printf("Warning: download of %s failed (attempt %d/%d, %d bytes transferred): %v\n", file.Name, attempt+1, c.Retries+1, n, err)
+		/* local and permanent camera errors do not go away by retrying */
+		if !isTransient(err) {
+			break
+		}
+	}
+	err = fmt.Errorf("downloadFile(%s): %w", file.Name, err)
+	c.emit(Event{Type: EventDownloadEnd, File: path, Message: err.Error()})
+	return err
+}
@@ -0,0 +1,102 @@
+package astrocam
+
+import (
+	"context"
+	"errors"
+	"github.com/jonmol/gphoto2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/* newFakeCamera creates session using fake backend with the specified settings */
+func newFakeCamera(t *testing.T, settings map[string]string) (*Camera, *fakeBackend) {
+	backend := newFakeBackend(settings)
+	c := New()
+	c.camera = backend
+	c.Target = t.TempDir()
+	c.checksums = make(map[string]string)
+	return c, backend
+}
+
+func TestDownloadDisconnect(t *testing.T) {
+	c, backend := newFakeCamera(t, nil)
+	backend.files["IMG_0001.CR2"] = make([]byte, 4096)
+	backend.downloadErrors["IMG_0001.CR2"] = &gphoto2.GphotoError{Code: gphoto2.ErrorIOUSBFind}
+	path := filepath.Join(c.Target, "IMG_0001.CR2")
+	err := c.downloadFile(context.Background(), backend.file("IMG_0001.CR2"), path)
+	if !errors.Is(err, ErrDisconnected) {
+		t.Fatalf("downloadFile() = %v, want ErrDisconnected", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("partial file %s left behind: %v", path, err)
+	}
+	/* disconnect is not retried on the dead connection, the frame is pulled again after reconnect */
+	if n := backend.downloads["IMG_0001.CR2"]; n != 1 {
+		t.Errorf("downloaded %d times, want 1", n)
+	}
+	if _, ok := backend.files["IMG_0001.CR2"]; !ok {
+		t.Errorf("frame removed from the card")
+	}
+}
+
+func TestDownloadTransientRetry(t *testing.T) {
+	c, backend := newFakeCamera(t, nil)
+	backend.files["IMG_0001.CR2"] = make([]byte, 4096)
+	backend.downloadErrors["IMG_0001.CR2"] = &gphoto2.GphotoError{Code: gphoto2.ErrorIO}
+	c.Retries = 1
+	path := filepath.Join(c.Target, "IMG_0001.CR2")
+	err := c.downloadFile(context.Background(), backend.file("IMG_0001.CR2"), path)
+	if !errors.Is(err, ErrTransient) {
+		t.Fatalf("downloadFile() = %v, want ErrTransient", err)
+	}
+	if n := backend.downloads["IMG_0001.CR2"]; n != 2 {
+		t.Errorf("downloaded %d times, want 2", n)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("partial file %s left behind: %v", path, err)
+	}
+}
+
+func TestDownloadEmptyFile(t *testing.T) {
+	c, backend := newFakeCamera(t, nil)
+	backend.files["IMG_0001.CR2"] = make([]byte, 4096)
+	backend.empty["IMG_0001.CR2"] = 2
+	c.Retries = 1
+	path := filepath.Join(c.Target, "IMG_0001.CR2")
+	err := c.downloadFile(context.Background(), backend.file("IMG_0001.CR2"), path)
+	if !errors.Is(err, ErrTransient) {
+		t.Fatalf("downloadFile() = %v, want ErrTransient", err)
+	}
+	if n := backend.downloads["IMG_0001.CR2"]; n != 2 {
+		t.Errorf("downloaded %d times, want 2", n)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("empty file %s left behind: %v", path, err)
+	}
+}
+
+func TestDownloadEmptyFileRetried(t *testing.T) {
+	c, backend := newFakeCamera(t, nil)
+	backend.files["IMG_0001.CR2"] = []byte("frame data")
+	backend.empty["IMG_0001.CR2"] = 1
+	c.Retries = 1
+	path := filepath.Join(c.Target, "IMG_0001.CR2")
+	if err := c.downloadFile(context.Background(), backend.file("IMG_0001.CR2"), path); err != nil {
+		t.Fatalf("downloadFile() = %v, want nil", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "frame data" {
+		t.Errorf("downloaded %q (%v), want frame data", data, err)
+	}
+}
+
+func TestConfigureMaxFrameSize(t *testing.T) {
+	for _, size := range []int64{-1, 0, 1024} {
+		c := New()
+		c.MaxFrameSize = size
+		if err := c.Configure(); (err != nil) != (size < 0) {
+			t.Errorf("Configure() with max frame size %d = %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+/* EphemerisInterval is the interval of re-checking ephemeris gate while capture is paused */
+const EphemerisInterval = time.Minute
+
+/* EphemerisGate decides whether frames should be captured at the specified time */
+type EphemerisGate interface {
+	ShouldCapture(now time.Time) (bool, string)
+}
+
+/* MoonGate allows capture while the moon is below the maximal altitude or less illuminated than the maximal phase */
+type MoonGate struct {
+	Source      LocationSource
+	MaxAltitude float64
+	MaxPhase    float64
+}
+
+/* ShouldCapture checks moon position for current location, capture is allowed when location is not available */
+func (g MoonGate) ShouldCapture(now time.Time) (bool, string) {
+	location, err := g.Source.Location()
+	if err != nil {
+		log.Printf("Warning: moon gate: %v\n", err)
+		return true, ""
+	}
+	altitude, phase := moonPosition(now, location)
+	if altitude < g.MaxAltitude || phase*100 < g.MaxPhase {
+		return true, ""
+	}
+	return false, fmt.Sprintf("moon at %.0f degrees altitude, %.0f%% illuminated", altitude, phase*100)
+}
+
+/* degrees based trigonometric helpers */
+func sinDeg(x float64) float64 { return math.Sin(x * math.Pi / 180) }
+func cosDeg(x float64) float64 { return math.Cos(x * math.Pi / 180) }
+
+/* moonPosition returns approximate topocentric altitude of the moon in degrees and its illuminated fraction, accurate to about a degree */
+func moonPosition(t time.Time, location Location) (altitude, phase float64) {
+	/* days and centuries since J2000.0 */
+	d := float64(t.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5 - 2451545.0
+	T := d / 36525
+	/* ecliptic coordinates of the moon, low precision series of the astronomical almanac */
+	lon := 218.32 + 481267.881*T +
+		6.29*sinDeg(135.0+477198.87*T) - 1.27*sinDeg(259.3-413335.36*T) +
+		0.66*sinDeg(235.7+890534.22*T) + 0.21*sinDeg(269.9+954397.74*T) -
+		0.19*sinDeg(357.5+35999.05*T) - 0.11*sinDeg(186.5+966404.03*T)
+	lat := 5.13*sinDeg(93.3+483202.02*T) + 0.28*sinDeg(228.2+960400.89*T) -
+		0.28*sinDeg(318.3+6003.15*T) - 0.17*sinDeg(217.6-407332.21*T)
+	/* equatorial coordinates */
+	obliquity := 23.4393 - 0.0130*T
+	dec := math.Asin(sinDeg(lat)*cosDeg(obliquity)+cosDeg(lat)*sinDeg(obliquity)*sinDeg(lon)) * 180 / math.Pi
+	ra := math.Atan2(sinDeg(lon)*cosDeg(obliquity)-math.Tan(lat*math.Pi/180)*sinDeg(obliquity), cosDeg(lon)) * 180 / math.Pi
+	/* altitude from local hour angle, corrected for lunar parallax */
+	sidereal := 280.46061837 + 360.98564736629*d + location.Longitude
+	hour := sidereal - ra
+	altitude = math.Asin(sinDeg(location.Latitude)*sinDeg(dec)+cosDeg(location.Latitude)*cosDeg(dec)*cosDeg(hour)) * 180 / math.Pi
+	altitude -= 0.95 * cosDeg(altitude)
+	/* illuminated fraction from elongation of the moon from the sun */
+	g := 357.529 + 0.98560028*d
+	sun := 280.459 + 0.98564736*d + 1.915*sinDeg(g) + 0.020*sinDeg(2*g)
+	elongation := cosDeg(lat) * cosDeg(lon-sun)
+	phase = (1 - elongation) / 2
+	return altitude, phase
+}
+
+/* waitEphemeris pauses capture before the specified frame until ephemeris gate allows capturing or context is cancelled */
+func (c *Camera) waitEphemeris(ctx context.Context, frame int) error {
+	capture, reason := c.Ephemeris.ShouldCapture(time.Now())
+	if capture {
+		return nil
+	}
+	pause := PauseEvent{Start: time.Now(), Frame: frame, Reason: reason}
+	fmt.Printf("\n%sPausing before frame %d: %s\n", c.prefix(), frame, reason)
+	for !capture {
+		if err := c.wait(ctx, EphemerisInterval); err != nil {
+			pause.End = time.Now()
+			c.Summary.Pauses = append(c.Summary.Pauses, pause)
+			return fmt.Errorf("waitEphemeris: %w", err)
+		}
+		/* stop request ends the pause, capture loop then stops before the next frame */
+		if c.Stop.Stopped() {
+			pause.End = time.Now()
+			c.Summary.Pauses = append(c.Summary.Pauses, pause)
+			return nil
+		}
+		capture, _ = c.Ephemeris.ShouldCapture(time.Now())
+	}
+	pause.End = time.Now()
+	c.Summary.Pauses = append(c.Summary.Pauses, pause)
+	fmt.Printf("%sResuming after %v\n", c.prefix(), pause.End.Sub(pause.Start).Round(time.Second))
+	return nil
+}
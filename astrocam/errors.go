@@ -0,0 +1,102 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"log"
+	"time"
+)
+
+var (
+	/* ErrTransient marks camera errors which are likely to succeed when retried */
+	ErrTransient = errors.New("transient camera error")
+	/* ErrDisconnected marks errors caused by camera no longer being reachable */
+	ErrDisconnected = errors.New("camera disconnected")
+	/* ErrBadValue marks setting values rejected or not supported by the camera */
+	ErrBadValue = errors.New("bad camera setting value")
+	/* ErrNotSupported is wrapped by errors of settings the camera body does not have */
+	ErrNotSupported = errors.New("setting not supported")
+	/* ErrCardFull marks errors caused by memory card running out of space */
+	ErrCardFull = errors.New("memory card full")
+)
+
+/* CameraError wraps an error of a camera operation with its class, one of the Err* class errors or nil when permanent */
+type CameraError struct {
+	Op    string
+	Class error
+	Err   error
+}
+
+/* Error returns operation and underlying error message */
+func (e *CameraError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+/* Unwrap returns the underlying error, e.g. *gphoto2.GphotoError */
+func (e *CameraError) Unwrap() error {
+	return e.Err
+}
+
+/* Is reports whether error belongs to the specified class */
+func (e *CameraError) Is(target error) bool {
+	return e.Class != nil && e.Class == target
+}
+
+/* errorClass maps gphoto2 result codes to error classes, generic error is also returned for permanent failures so it is not retried */
+func errorClass(code int) error {
+	switch code {
+	case gphoto2.ErrorIO, gphoto2.ErrorTimeout, gphoto2.ErrorIORead, gphoto2.ErrorIOWrite,
+		gphoto2.ErrorIOUpdate, gphoto2.ErrorIOUSBClearHalt, gphoto2.ErrorIOLock, gphoto2.ErrorCameraBusy,
+		gphoto2.ErrorCorruptedData:
+		return ErrTransient
+	case gphoto2.ErrorUnknownPort, gphoto2.ErrorIOInit, gphoto2.ErrorIOUSBFind, gphoto2.ErrorIOUSBClaim,
+		gphoto2.ErrorModelNotFound:
+		return ErrDisconnected
+	case gphoto2.ErrorBadParameters, gphoto2.ErrorNotSupported, gphoto2.ErrorReadOnly,
+		gphoto2.ErrorWidgetHasNoOptions, gphoto2.ErrorWidgetIllegalOption, gphoto2.ErrorWidgetNotImplemented:
+		return ErrBadValue
+	case gphoto2.ErrorNoSpace:
+		return ErrCardFull
+	}
+	return nil
+}
+
+/* cameraError classifies error of the named camera operation, errors which are already classified are kept */
+func cameraError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *CameraError
+	if errors.As(err, &classified) {
+		return err
+	}
+	e := &CameraError{Op: op, Err: err}
+	var gpErr *gphoto2.GphotoError
+	if errors.As(err, &gpErr) {
+		e.Class = errorClass(gpErr.Code)
+	}
+	return e
+}
+
+/* isTransient reports whether failed operation is worth retrying */
+func isTransient(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
+
+/* RetryBackoff is the pause before the first retry of a failed camera operation, doubled with every further retry */
+const RetryBackoff = time.Millisecond * 500
+
+/* withRetry runs camera operation up to attempts times, transient failures are retried with growing backoff and the last error is returned */
+func (c *Camera) withRetry(attempts int, fn func() error) (err error) {
+	backoff := RetryBackoff
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !isTransient(err) || attempt >= attempts {
+			return err
+		}
+		log.Printf("Warning: %s%v, retrying in %v (attempt %d/%d)\n", c.prefix(), err, backoff, attempt, attempts)
+		c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.Retries++ })
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
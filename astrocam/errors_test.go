@@ -0,0 +1,60 @@
+package astrocam
+
+import (
+	"errors"
+	"github.com/jonmol/gphoto2"
+	"testing"
+)
+
+func TestCameraErrorClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  int
+		class error
+	}{
+		{"generic", gphoto2.Error, nil},
+		{"io", gphoto2.ErrorIO, ErrTransient},
+		{"timeout", gphoto2.ErrorTimeout, ErrTransient},
+		{"busy", gphoto2.ErrorCameraBusy, ErrTransient},
+		{"usb find", gphoto2.ErrorIOUSBFind, ErrDisconnected},
+		{"model not found", gphoto2.ErrorModelNotFound, ErrDisconnected},
+		{"not supported", gphoto2.ErrorNotSupported, ErrBadValue},
+		{"no space", gphoto2.ErrorNoSpace, ErrCardFull},
+	}
+	classes := []error{ErrTransient, ErrDisconnected, ErrBadValue, ErrCardFull}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cameraError("op", &gphoto2.GphotoError{Code: tt.code})
+			for _, class := range classes {
+				if got, want := errors.Is(err, class), class == tt.class; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, class, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		attempts int
+	}{
+		{"generic error is not retried", gphoto2.Error, 1},
+		{"transient error is retried", gphoto2.ErrorIO, 2},
+		{"disconnect is not retried", gphoto2.ErrorIOUSBFind, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			attempts := 0
+			err := c.withRetry(2, func() error {
+				attempts++
+				return cameraError("op", &gphoto2.GphotoError{Code: tt.code})
+			})
+			if err == nil || attempts != tt.attempts {
+				t.Errorf("withRetry() = %v after %d attempts, want error after %d", err, attempts, tt.attempts)
+			}
+		})
+	}
+}
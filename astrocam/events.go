@@ -0,0 +1,101 @@
+package astrocam
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* event types of the session event stream */
+const (
+	EventSessionStart  = "session_start"
+	EventSessionEnd    = "session_end"
+	EventFrameStart    = "frame_start"
+	EventFrameEnd      = "frame_end"
+	EventDownloadStart = "download_start"
+	EventDownloadEnd   = "download_end"
+	EventBattery       = "battery"
+	EventWarning       = "warning"
+	EventError         = "error"
+)
+
+/* Event is a single entry of the session event stream, Type discriminates which fields are set */
+type Event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Camera  string    `json:"camera,omitempty"`
+	Kind    string    `json:"kind,omitempty"`
+	Frame   int       `json:"frame,omitempty"`
+	File    string    `json:"file,omitempty"`
+	Bytes   int64     `json:"bytes,omitempty"`
+	Battery string    `json:"battery,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+/* EventWriter writes events as newline delimited json, each event is written with a single unbuffered write */
+type EventWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+/* NewEventWriter creates event writer appending to the file at path, or writing to stdout if path is "-" */
+func NewEventWriter(path string) (*EventWriter, error) {
+	if path == "-" {
+		return &EventWriter{w: os.Stdout}, nil
+	}
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &EventWriter{w: fh}, nil
+}
+
+/* Emit writes event, it does nothing on nil writer */
+func (w *EventWriter) Emit(event Event) {
+	if w == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.w.Write(append(data, '\n'))
+}
+
+/* Write turns log lines with warning or error prefix into events, so it can be attached to the standard logger */
+func (w *EventWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(bytes.TrimSpace(p)), "\n") {
+		/* log timestamp and camera label precede the level */
+		if i := strings.Index(line, "Warning: "); i >= 0 {
+			w.Emit(Event{Type: EventWarning, Message: line[i+len("Warning: "):]})
+		} else if i := strings.Index(line, "Error: "); i >= 0 {
+			w.Emit(Event{Type: EventError, Message: line[i+len("Error: "):]})
+		}
+	}
+	return len(p), nil
+}
+
+/* emit writes event of this camera to the event stream */
+func (c *Camera) emit(event Event) {
+	event.Camera = c.Label
+	if event.Kind == "" {
+		event.Kind = c.Kind
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	c.Events.Emit(event)
+	/* progress hook of programs using astrocam as a library */
+	if c.OnEvent != nil {
+		c.OnEvent(event)
+	}
+}
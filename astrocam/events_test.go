@@ -0,0 +1,42 @@
+package astrocam
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWarningEvents(t *testing.T) {
+	var buffer bytes.Buffer
+	events := &EventWriter{w: &buffer}
+	log.SetOutput(io.MultiWriter(os.Stderr, events))
+	defer log.SetOutput(os.Stderr)
+	/* card and battery running out during session are reported as warnings */
+	c, _ := newFakeCamera(t, map[string]string{AvailableShots: "5", BatteryLevel: "10%"})
+	c.readBattery()
+	c.checkAvailableShots(10)
+	c.checkBatteryFrames(1000)
+	messages := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("bad event %q: %v", line, err)
+		}
+		if event.Type == EventWarning {
+			messages = append(messages, event.Message)
+		}
+	}
+	for _, want := range []string{"card will fill", "battery will run out"} {
+		found := false
+		for _, message := range messages {
+			found = found || strings.Contains(message, want)
+		}
+		if !found {
+			t.Errorf("warning events %q, want one about %q", messages, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package astrocam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	/* ExifHeaderLimit is the number of bytes read from frame files to look up exif data */
+	ExifHeaderLimit = 1 << 20
+
+	tagExifIFD      = 0x8769
+	tagExposureTime = 0x829a
+	tagISO          = 0x8827
+	typeShort       = 3
+	typeRational    = 5
+)
+
+/* ExifInfo holds exposure parameters read back from frame exif data */
+type ExifInfo struct {
+	ExposureTime time.Duration
+	ISO          int
+}
+
+/* tiffEntry is a tiff image file directory entry */
+type tiffEntry struct {
+	Type  uint16
+	Count uint32
+	Value uint32
+}
+
+/* findTiffTag looks up tag in the image file directory at specified offset */
+func findTiffTag(data []byte, order binary.ByteOrder, offset uint32, tag uint16) (tiffEntry, error) {
+	if int64(offset)+2 > int64(len(data)) {
+		return tiffEntry{}, fmt.Errorf("ifd offset out of range")
+	}
+	count := int(order.Uint16(data[offset:]))
+	for i := 0; i < count; i++ {
+		start := int64(offset) + 2 + int64(i)*12
+		if start+12 > int64(len(data)) {
+			break
+		}
+		entry := data[start : start+12]
+		if order.Uint16(entry) == tag {
+			return tiffEntry{
+				Type:  order.Uint16(entry[2:]),
+				Count: order.Uint32(entry[4:]),
+				Value: order.Uint32(entry[8:]),
+			}, nil
+		}
+	}
+	return tiffEntry{}, fmt.Errorf("tag 0x%04x not found", tag)
+}
+
+/* tiffExif reads exposure parameters from exif data of a tiff structure */
+func tiffExif(data []byte) (info ExifInfo, err error) {
+	if len(data) < 8 {
+		return info, fmt.Errorf("tiff header too short")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return info, fmt.Errorf("not a tiff structure")
+	}
+	exif, err := findTiffTag(data, order, order.Uint32(data[4:]), tagExifIFD)
+	if err != nil {
+		return info, err
+	}
+	/* exposure time is a rational value stored at offset */
+	if exposure, err := findTiffTag(data, order, exif.Value, tagExposureTime); err == nil {
+		if exposure.Type == typeRational && int64(exposure.Value)+8 <= int64(len(data)) {
+			numerator := order.Uint32(data[exposure.Value:])
+			denominator := order.Uint32(data[exposure.Value+4:])
+			if denominator != 0 {
+				info.ExposureTime = time.Duration(float64(numerator) / float64(denominator) * float64(time.Second))
+			}
+		}
+	}
+	/* iso is a short value stored inline */
+	if iso, err := findTiffTag(data, order, exif.Value, tagISO); err == nil && iso.Type == typeShort {
+		entry := make([]byte, 4)
+		order.PutUint32(entry, iso.Value)
+		info.ISO = int(order.Uint16(entry))
+	}
+	if info.ExposureTime == 0 && info.ISO == 0 {
+		return info, fmt.Errorf("exposure parameters not found")
+	}
+	return info, nil
+}
+
+/* jpegExifData returns tiff structure of the exif segment of jpeg data */
+func jpegExifData(data []byte) ([]byte, error) {
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xff {
+			break
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if pos+2+length > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+		/* exif segment always precedes image data */
+		if marker == 0xda {
+			break
+		}
+		pos += 2 + length
+	}
+	return nil, fmt.Errorf("exif segment not found")
+}
+
+/* readExif reads exposure parameters from exif data of a jpeg or tiff based raw frame file */
+func readExif(path string) (ExifInfo, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return ExifInfo{}, err
+	}
+	defer fh.Close()
+	data, err := io.ReadAll(io.LimitReader(fh, ExifHeaderLimit))
+	if err != nil {
+		return ExifInfo{}, err
+	}
+	if bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		if data, err = jpegExifData(data); err != nil {
+			return ExifInfo{}, fmt.Errorf("readExif(%s): %w", path, err)
+		}
+	}
+	info, err := tiffExif(data)
+	if err != nil {
+		return info, fmt.Errorf("readExif(%s): %w", path, err)
+	}
+	return info, nil
+}
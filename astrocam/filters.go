@@ -0,0 +1,153 @@
+package astrocam
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+/* FilterWheel moves the named filter in front of the sensor */
+type FilterWheel interface {
+	Select(name string) error
+}
+
+/* FilterReporter is implemented by filter wheels able to report the filter in place */
+type FilterReporter interface {
+	Position() (string, error)
+}
+
+/* NoFilterWheel is used without filter wheel control, e.g. with manually changed filters */
+type NoFilterWheel struct{}
+
+/* Select does nothing */
+func (NoFilterWheel) Select(name string) error {
+	return nil
+}
+
+/* CommandFilterWheel selects filters by running a shell command with filter name as its $1 argument */
+type CommandFilterWheel struct {
+	Command string
+	/* PositionCommand prints name of the filter in place, empty when the wheel does not report it */
+	PositionCommand string
+}
+
+/* Select runs filter command for the specified filter */
+func (w CommandFilterWheel) Select(name string) error {
+	cmd := exec.Command("sh", "-c", w.Command, "sh", name)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("CommandFilterWheel(%s): %w", name, err)
+	}
+	return nil
+}
+
+/* Position runs position command and returns the filter name it prints */
+func (w CommandFilterWheel) Position() (string, error) {
+	if w.PositionCommand == "" {
+		return "", fmt.Errorf("CommandFilterWheel: position not reported")
+	}
+	cmd := exec.Command("sh", "-c", w.PositionCommand)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("CommandFilterWheel(position): %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+/* FilterEntry is a number of frames captured through the named filter */
+type FilterEntry struct {
+	Name   string
+	Frames int
+}
+
+/* FilterPlan is an ordered list of filters and frame counts */
+type FilterPlan []FilterEntry
+
+/* String formats filter plan as a comma separated list of name:frames items */
+func (p *FilterPlan) String() string {
+	items := make([]string, 0, len(*p))
+	for _, entry := range *p {
+		items = append(items, fmt.Sprintf("%s:%d", entry.Name, entry.Frames))
+	}
+	return strings.Join(items, ",")
+}
+
+/* Set parses a comma separated list of name:frames items, e.g. 'Ha:20,OIII:20' */
+func (p *FilterPlan) Set(value string) error {
+	plan := FilterPlan{}
+	for _, item := range strings.Split(value, ",") {
+		name, count, ok := strings.Cut(strings.TrimSpace(item), ":")
+		frames, err := strconv.Atoi(count)
+		if !ok || name == "" || strings.ContainsAny(name, "/\\") || err != nil || frames <= 0 {
+			return fmt.Errorf("bad filter %q (must be name:frames with a positive number of frames)", item)
+		}
+		plan = append(plan, FilterEntry{Name: name, Frames: frames})
+	}
+	*p = plan
+	return nil
+}
+
+/* CheckFilters validates filter plan and returns total number of planned frames */
+func (c *Camera) CheckFilters() (int, error) {
+	if c.Sweep || c.DeferDownload || c.resumed != 0 {
+		return 0, fmt.Errorf("Option -filters can not be used with -sweep, -defer-download or -resume-from-card")
+	}
+	total := 0
+	for _, entry := range c.Filters {
+		total += entry.Frames
+	}
+	return total, nil
+}
+
+/* selectFilter selects the named filter, verifying its position where the wheel reports it */
+func (c *Camera) selectFilter(name string) error {
+	/* without filter wheel control the user changes filters */
+	if _, manual := c.FilterWheel.(NoFilterWheel); manual && !c.AssumeYes {
+		fmt.Printf("\n%sPut %s filter in place and press Enter to continue... ", c.prefix(), name)
+		if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+			return fmt.Errorf("selectFilter: %w", err)
+		}
+	}
+	if err := c.FilterWheel.Select(name); err != nil {
+		return err
+	}
+	reporter, ok := c.FilterWheel.(FilterReporter)
+	if !ok {
+		return nil
+	}
+	position, err := reporter.Position()
+	if err != nil {
+		log.Printf("Warning: unable to verify filter position: %v\n", err)
+		return nil
+	}
+	if !strings.EqualFold(position, name) {
+		return fmt.Errorf("selectFilter: %s selected but filter wheel reports %s", name, position)
+	}
+	return nil
+}
+
+/* FilterLoop captures frames through every filter of the plan to filter subdirectories of kind directory */
+func (c *Camera) FilterLoop(ctx context.Context) error {
+	for _, entry := range c.Filters {
+		/* capture loop returns after the last frame completes, so filters never change mid frame */
+		if err := c.selectFilter(entry.Name); err != nil {
+			return err
+		}
+		c.Filter, c.Frames, c.subDir = entry.Name, entry.Frames, entry.Name
+		fmt.Printf("\n%sCapturing %d frames through %s filter\n", c.prefix(), c.Frames, c.Filter)
+		if err := c.CaptureLoop(ctx); err != nil {
+			return err
+		}
+		if c.Stop.Stopped() {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package astrocam
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	/* FITSBlockSize is the size of fits header and data blocks */
+	FITSBlockSize = 2880
+	/* FITSCardSize is the size of a single fits header card */
+	FITSCardSize = 80
+	/* FITSDateLayout is the format of fits DATE-OBS values */
+	FITSDateLayout = "2006-01-02T15:04:05.000"
+)
+
+/* RawToFITS converts camera raw data to a fits file, header keywords describing the exposure are added afterwards */
+type RawToFITS interface {
+	Convert(raw []byte, name, dst string) error
+}
+
+/* CommandFITSConverter converts raw data by running a shell command with raw file as $1 and destination fits file as $2 */
+type CommandFITSConverter struct {
+	Command string
+}
+
+/* Convert writes raw data to a temporary file next to destination and runs converter command on it */
+func (f CommandFITSConverter) Convert(raw []byte, name, dst string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".astro-*"+filepath.Ext(name))
+	if err != nil {
+		return fmt.Errorf("CommandFITSConverter(%s): %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("CommandFITSConverter(%s): %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("CommandFITSConverter(%s): %w", name, err)
+	}
+	cmd := exec.Command("sh", "-c", f.Command, "sh", tmp.Name(), dst)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("CommandFITSConverter(%s): %w", name, err)
+	}
+	return nil
+}
+
+/* fitsCard formats a single 80 character header card with string, integer or floating point value */
+func fitsCard(key string, value interface{}) string {
+	var card string
+	switch v := value.(type) {
+	case string:
+		card = fmt.Sprintf("%-8s= '%-8s'", key, strings.ReplaceAll(v, "'", "''"))
+	case int:
+		card = fmt.Sprintf("%-8s= %20d", key, v)
+	case float64:
+		number := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(number, ".") {
+			number += ".0"
+		}
+		card = fmt.Sprintf("%-8s= %20s", key, number)
+	}
+	if len(card) > FITSCardSize {
+		return card[:FITSCardSize]
+	}
+	return card + strings.Repeat(" ", FITSCardSize-len(card))
+}
+
+/* fitsCards returns header cards describing exposure of the frame */
+func (c *Camera) fitsCards(record *FrameRecord) []string {
+	exposure := record.Requested
+	if exposure == 0 {
+		exposure = record.End.Sub(record.Start)
+	}
+	cards := []string{
+		fitsCard("EXPTIME", exposure.Seconds()),
+		fitsCard("DATE-OBS", record.Start.UTC().Format(FITSDateLayout)),
+		fitsCard("INSTRUME", c.Model),
+	}
+	if iso, err := strconv.Atoi(record.ISO); err == nil {
+		cards = append(cards, fitsCard("ISO", iso))
+	}
+	if c.Telescope != "" {
+		cards = append(cards, fitsCard("TELESCOP", c.Telescope))
+	}
+	if record.Filter != "" {
+		cards = append(cards, fitsCard("FILTER", record.Filter))
+	}
+	return cards
+}
+
+/* writeFITSHeader replaces primary header keywords of fits file with the specified cards, keeping all other cards and data */
+func writeFITSHeader(path string, cards []string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("writeFITSHeader: %w", err)
+	}
+	if !bytes.HasPrefix(data, []byte("SIMPLE  =")) {
+		return fmt.Errorf("writeFITSHeader(%s): not a fits file", path)
+	}
+	replaced := make(map[string]bool)
+	for _, card := range cards {
+		replaced[strings.TrimSpace(card[:8])] = true
+	}
+	header := []string{}
+	end := -1
+	for offset := 0; offset+FITSCardSize <= len(data); offset += FITSCardSize {
+		card := string(data[offset : offset+FITSCardSize])
+		key := strings.TrimSpace(card[:8])
+		if key == "END" {
+			end = offset + FITSCardSize
+			break
+		}
+		if !replaced[key] {
+			header = append(header, card)
+		}
+	}
+	if end < 0 {
+		return fmt.Errorf("writeFITSHeader(%s): header has no END card", path)
+	}
+	/* data starts at the block following the END card */
+	dataStart := (end + FITSBlockSize - 1) / FITSBlockSize * FITSBlockSize
+	if dataStart > len(data) {
+		dataStart = len(data)
+	}
+	header = append(header, cards...)
+	header = append(header, "END"+strings.Repeat(" ", FITSCardSize-3))
+	out := []byte(strings.Join(header, ""))
+	if pad := len(out) % FITSBlockSize; pad != 0 {
+		out = append(out, bytes.Repeat([]byte(" "), FITSBlockSize-pad)...)
+	}
+	out = append(out, data[dataStart:]...)
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("writeFITSHeader: %w", err)
+	}
+	return nil
+}
+
+/* downloadToMemory downloads camera file to memory, retrying transient failures until context is cancelled */
+func (c *Camera) downloadToMemory(ctx context.Context, file gphoto2.CameraFilePath) (data []byte, err error) {
+	c.emit(Event{Type: EventDownloadStart, File: file.Name})
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt != 0 {
+			c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.Retries++ })
+			if e := sleepContext(ctx, DownloadRetryDelay); e != nil {
+				err = e
+				break
+			}
+		}
+		buffer := new(bytes.Buffer)
+		counter := &countingWriter{w: buffer, limit: c.MaxFrameSize << 20}
+		if err = c.camera.Download(file, counter); err == nil && counter.n == 0 {
+			err = &CameraError{Op: "downloadToMemory", Class: ErrTransient, Err: fmt.Errorf("empty file transferred")}
+		}
+		if err == nil {
+			c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.DownloadBytes += counter.n })
+			c.emit(Event{Type: EventDownloadEnd, File: file.Name, Bytes: counter.n})
+			return buffer.Bytes(), nil
+		}
+		err = cameraError("DownloadImage", err)
+		log.Printf("Warning: download of %s failed (attempt %d/%d, %d bytes transferred): %v\n", file.Name, attempt+1, c.Retries+1, counter.n, err)
+		if !isTransient(err) {
+			break
+		}
+	}
+	err = fmt.Errorf("downloadToMemory(%s): %w", file.Name, err)
+	c.emit(Event{Type: EventDownloadEnd, File: file.Name, Message: err.Error()})
+	return nil, err
+}
+
+/* downloadFITS downloads raw frame to memory and converts it to fits next to path, raw is written to path only if it is kept */
+func (c *Camera) downloadFITS(ctx context.Context, file gphoto2.CameraFilePath, path string, record *FrameRecord) (string, error) {
+	raw, err := c.downloadToMemory(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	/* existing fits files, e.g. after camera file numbering wrapped, are never overwritten */
+	dst := uniquePath(strings.TrimSuffix(path, filepath.Ext(path)) + ".fits")
+	if err := c.FITS.Convert(raw, file.Name, dst); err != nil {
+		return "", fmt.Errorf("downloadFITS: %w", err)
+	}
+	if err := writeFITSHeader(dst, c.fitsCards(record)); err != nil {
+		os.Remove(dst)
+		return "", fmt.Errorf("downloadFITS: %w", err)
+	}
+	if c.FITSKeepRaw {
+		if err := os.WriteFile(path, raw, 0644); err != nil {
+			return "", fmt.Errorf("downloadFITS: %w", err)
+		}
+		sum := sha256.Sum256(raw)
+		if err := c.writeChecksum(path, hex.EncodeToString(sum[:])); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+		c.storeFrame(path)
+		name, _ := filepath.Rel(c.frameDir, path)
+		record.Files = append(record.Files, name)
+	}
+	return dst, nil
+}
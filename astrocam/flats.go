@@ -0,0 +1,81 @@
+package astrocam
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/* meterPreview measures brightness of a preview frame, the frame is saved to target only if requested */
+func (c *Camera) meterPreview(iteration int) (float64, error) {
+	data, img, err := c.capturePreview()
+	if err != nil {
+		return 0, err
+	}
+	if c.SaveTestFrames {
+		/* metering runs before the first frame creates kind directory */
+		dir := c.targetPath(time.Now())
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, err
+		}
+		name := filepath.Join(dir, fmt.Sprintf("metering-%02d.jpg", iteration))
+		if err := os.WriteFile(name, data, 0644); err != nil {
+			return 0, err
+		}
+	}
+	return MeanBrightness(img), nil
+}
+
+/* MeterFlats searches camera shutter speeds for the one closest to the requested flats brightness */
+func (c *Camera) MeterFlats() error {
+	setting, err := c.camera.GetSetting("shutterspeed")
+	if err != nil {
+		return fmt.Errorf("MeterFlats(shutterspeed): %w", err)
+	}
+	choices, err := setting.Options()
+	if err != nil {
+		return fmt.Errorf("MeterFlats(options): %w", err)
+	}
+	/* shutter speeds are enumerated from longest to shortest */
+	speeds := []string{}
+	for _, choice := range choices {
+		if choice != "bulb" && choice != "auto" {
+			speeds = append(speeds, choice)
+		}
+	}
+	if len(speeds) == 0 {
+		return fmt.Errorf("MeterFlats: no shutter speeds available")
+	}
+	fmt.Printf("Metering flats exposure... ")
+	best, bestDiff := "", math.Inf(1)
+	low, high := 0, len(speeds)-1
+	for iteration := 1; low <= high; iteration++ {
+		middle := (low + high) / 2
+		if err := c.SetConfig("shutterspeed", speeds[middle]); err != nil {
+			fmt.Printf("Error!\n")
+			return fmt.Errorf("MeterFlats(%s): %w", speeds[middle], err)
+		}
+		brightness, err := c.meterPreview(iteration)
+		if err != nil {
+			fmt.Printf("Error!\n")
+			return err
+		}
+		if diff := math.Abs(brightness - c.FlatsBrightness); diff < bestDiff {
+			best, bestDiff = speeds[middle], diff
+		}
+		if brightness > c.FlatsBrightness {
+			low = middle + 1
+		} else {
+			high = middle - 1
+		}
+	}
+	if err := c.SetConfig("shutterspeed", best); err != nil {
+		fmt.Printf("Error!\n")
+		return fmt.Errorf("MeterFlats(%s): %w", best, err)
+	}
+	c.Shutter = best
+	fmt.Printf("%s\n", best)
+	return nil
+}
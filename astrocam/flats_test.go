@@ -0,0 +1,38 @@
+package astrocam
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMeterPreviewSaveTestFrames(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+	var data bytes.Buffer
+	if err := jpeg.Encode(&data, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	c, backend := newFakeCamera(t, nil)
+	backend.preview = data.Bytes()
+	/* single camera session with a fresh target has no kind directory yet */
+	c.Target = filepath.Join(c.Target, "new")
+	c.Kind = "flats"
+	c.SaveTestFrames = true
+	brightness, err := c.meterPreview(1)
+	if err != nil {
+		t.Fatalf("meterPreview() = %v", err)
+	}
+	if math.Abs(brightness-0.5) > 0.05 {
+		t.Errorf("brightness %.2f, want 0.5", brightness)
+	}
+	if _, err := os.Stat(filepath.Join(c.Target, "flats", "metering-01.jpg")); err != nil {
+		t.Errorf("metering frame not saved: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package astrocam
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+)
+
+/* RefocusEvent records the outcome of a periodic focus check */
+type RefocusEvent struct {
+	Frame     int
+	Time      time.Time
+	Score     float64
+	Drift     float64
+	Autofocus bool
+}
+
+/* FocusScore computes image sharpness as the variance of the laplacian of image luminance */
+func FocusScore(img image.Image) float64 {
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	draw.Draw(gray, bounds, img, bounds.Min, draw.Src)
+	/* luminance of pixel at specified position */
+	luma := func(x, y int) float64 {
+		return float64(gray.GrayAt(x, y).Y)
+	}
+	var sum, sumSq float64
+	count := 0
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
+			v := 4*luma(x, y) - luma(x-1, y) - luma(x+1, y) - luma(x, y-1) - luma(x, y+1)
+			sum += v
+			sumSq += v * v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	mean := sum / float64(count)
+	return sumSq/float64(count) - mean*mean
+}
+
+/* CheckFocus captures a preview frame and compares its sharpness against the session baseline */
+func (c *Camera) CheckFocus(frame int) error {
+	_, img, err := c.capturePreview()
+	if err != nil {
+		return fmt.Errorf("CheckFocus: %w", err)
+	}
+	event := RefocusEvent{
+		Frame: frame,
+		Time:  time.Now(),
+		Score: FocusScore(img),
+	}
+	defer func() {
+		c.Summary.Refocus = append(c.Summary.Refocus, event)
+	}()
+	/* first check after start or autofocus sets the baseline */
+	if c.FocusBaseline == 0 {
+		c.FocusBaseline = event.Score
+		return nil
+	}
+	event.Drift = (c.FocusBaseline - event.Score) / c.FocusBaseline * 100
+	if event.Drift <= c.RefocusThreshold {
+		return nil
+	}
+	log.Printf("Warning: focus drifted by %.1f%% after frame %d\n", event.Drift, frame)
+	if c.AutofocusCmd == "" {
+		return nil
+	}
+	/* invoke external autofocus command */
+	cmd := exec.Command("sh", "-c", c.AutofocusCmd)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("CheckFocus(autofocus): %w", err)
+	}
+	event.Autofocus = true
+	c.FocusBaseline = 0
+	return nil
+}
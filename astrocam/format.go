@@ -0,0 +1,111 @@
+package astrocam
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* FormatConfirmation is the text user has to type in order to confirm card format */
+const FormatConfirmation = "format"
+
+/* cardChecksum computes sha256 checksum of camera file by reading it from the card */
+func (c *Camera) cardChecksum(file gphoto2.CameraFilePath) (string, error) {
+	hash := sha256.New()
+	if err := c.camera.Download(file, hash); err != nil {
+		return "", cameraError("DownloadImage", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+/* undownloadedFiles returns camera files whose content is neither listed in target checksum manifest nor found in a local file of the same name */
+func (c *Camera) undownloadedFiles() (CameraFiles, error) {
+	sums, err := loadChecksums(c.Target)
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string][]string)
+	err = filepath.WalkDir(c.Target, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			local[entry.Name()] = append(local[entry.Name()], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	/* names alone do not prove download, an older frame may have the same name after camera file numbering wrapped */
+	missing := CameraFiles{}
+	for _, file := range c.Files {
+		sum, err := c.cardChecksum(file)
+		if err != nil {
+			return nil, err
+		}
+		found := sums[sum]
+		for _, path := range local[file.Name] {
+			if found {
+				break
+			}
+			if localSum, err := fileChecksum(path); err == nil && localSum == sum {
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, file)
+		}
+	}
+	return missing, nil
+}
+
+/* FormatCard removes all files from the camera memory card after they have been verified as downloaded */
+func (c *Camera) FormatCard() error {
+	if err := c.Files.LoadCameraFiles(c.camera); err != nil {
+		return fmt.Errorf("formatCard(list): %w", err)
+	}
+	/* list files on the card */
+	for _, file := range c.Files {
+		fmt.Printf("  %s/%s\n", file.Folder, file.Name)
+	}
+	fmt.Printf("SD Card Files: %d\n", len(c.Files))
+	if len(c.Files) == 0 {
+		return nil
+	}
+	/* refuse to format card with files missing in target directory, every file is read back from the card to compare contents */
+	fmt.Printf("Verifying %d files against %s...\n", len(c.Files), c.Target)
+	missing, err := c.undownloadedFiles()
+	if err != nil {
+		return fmt.Errorf("formatCard(target): %w", err)
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("formatCard: %d files not downloaded to %s (e.g. %s), refusing to format", len(missing), c.Target, missing[0].Name)
+	}
+	/* mandatory interactive confirmation */
+	if !c.AssumeYes {
+		fmt.Printf("All files will be removed from the card, type '%s' to confirm: ", FormatConfirmation)
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("formatCard(confirm): %w", err)
+		}
+		if strings.TrimSpace(answer) != FormatConfirmation {
+			return fmt.Errorf("formatCard: not confirmed, aborting")
+		}
+	}
+	/* the gphoto2 binding has no storage format call, so remove files one by one */
+	for i := range c.Files {
+		if err := c.camera.DeleteFile(&c.Files[i]); err != nil {
+			return fmt.Errorf("formatCard(%s): %w", c.Files[i].Name, err)
+		}
+	}
+	fmt.Printf("Removed %d files from the card.\n", len(c.Files))
+	c.Files = CameraFiles{}
+	return nil
+}
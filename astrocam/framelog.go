@@ -0,0 +1,100 @@
+package astrocam
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/* FrameLogEntry is a single line of the frame log describing one downloaded frame file */
+type FrameLogEntry struct {
+	Camera   string    `json:"camera,omitempty"`
+	Frame    int       `json:"frame"`
+	Kind     string    `json:"kind"`
+	File     string    `json:"file"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Exposure float64   `json:"exposure"`
+	Shutter  string    `json:"shutter"`
+	Duration int       `json:"duration,omitempty"`
+	ISO      string    `json:"iso"`
+	Aperture float64   `json:"aperture"`
+	Battery  string    `json:"battery"`
+}
+
+/* FrameLog appends frame log entries as json lines, every entry is synced to disk so a crash does not lose history */
+type FrameLog struct {
+	mu sync.Mutex
+	fh *os.File
+}
+
+/* NewFrameLog opens frame log appending to the file at path */
+func NewFrameLog(path string) (*FrameLog, error) {
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameLog{fh: fh}, nil
+}
+
+/* Write appends entry to the log and flushes it to disk, it does nothing on nil log */
+func (l *FrameLog) Write(entry FrameLogEntry) error {
+	if l == nil {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.fh.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return l.fh.Sync()
+}
+
+/* LogFrame appends exposure parameters, battery level read at exposure start and wall clock exposure times of the frame file to the frame log */
+func (c *Camera) LogFrame(frame int, filename string, start, end time.Time) error {
+	return c.writeFrameLog(frame, filename, c.ISO, start, end)
+}
+
+/* writeFrameLog appends frame log entry of the frame file exposed at the specified iso */
+func (c *Camera) writeFrameLog(frame int, filename, iso string, start, end time.Time) error {
+	entry := FrameLogEntry{
+		Camera:   c.Label,
+		Frame:    frame,
+		Kind:     c.Kind,
+		File:     filename,
+		Start:    start,
+		End:      end,
+		Exposure: end.Sub(start).Seconds(),
+		Shutter:  c.Shutter,
+		ISO:      iso,
+		Aperture: c.Aperture,
+		Battery:  c.Battery,
+	}
+	if c.Shutter == "bulb" {
+		entry.Duration = c.Duration
+	}
+	return c.FrameLog.Write(entry)
+}
+
+/* logFrame writes frame log entries of all files of the captured frame with iso confirmed by the camera, frames without downloaded files are logged with empty file name */
+func (c *Camera) logFrame(record FrameRecord) {
+	files := record.Files
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	for _, name := range files {
+		if name != "" {
+			name = filepath.Join(record.Dir, name)
+		}
+		if err := c.writeFrameLog(record.Frame, name, record.ISO, record.Start, record.End); err != nil {
+			log.Printf("Warning: frame log: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package astrocam
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+const (
+	/* FramingPreviewName is the file name of framing preview in target directory */
+	FramingPreviewName = "framing.png"
+	/* FramingRawName is the file name of the downloaded preview jpeg the overlay is drawn on */
+	FramingRawName = "framing.jpg"
+)
+
+/* FramingColor is the color of framing overlay lines */
+var FramingColor = color.RGBA{R: 255, A: 255}
+
+/* framingPreview decodes preview jpeg at path and draws rule of thirds grid and center crosshair over it */
+func framingPreview(path string) (image.Image, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("framingPreview: %w", err)
+	}
+	defer fh.Close()
+	src, err := jpeg.Decode(fh)
+	if err != nil {
+		return nil, fmt.Errorf("framingPreview(%s): %w", path, err)
+	}
+	bounds := src.Bounds()
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, src, bounds.Min, draw.Src)
+	w, h := bounds.Dx(), bounds.Dy()
+	/* rule of thirds grid */
+	for i := 1; i < 3; i++ {
+		for y := 0; y < h; y++ {
+			img.Set(bounds.Min.X+w*i/3, bounds.Min.Y+y, FramingColor)
+		}
+		for x := 0; x < w; x++ {
+			img.Set(bounds.Min.X+x, bounds.Min.Y+h*i/3, FramingColor)
+		}
+	}
+	/* center crosshair spanning a tenth of the shorter side */
+	cx, cy := bounds.Min.X+w/2, bounds.Min.Y+h/2
+	size := w
+	if h < size {
+		size = h
+	}
+	size /= 20
+	for d := -size; d <= size; d++ {
+		img.Set(cx+d, cy, FramingColor)
+		img.Set(cx, cy+d, FramingColor)
+	}
+	return img, nil
+}
+
+/* WriteFramingPreview captures a liveview frame and writes it with framing overlay to target directory */
+func (c *Camera) WriteFramingPreview() (string, error) {
+	data, _, err := c.capturePreview()
+	if err != nil {
+		return "", err
+	}
+	raw := filepath.Join(c.Target, FramingRawName)
+	if err := os.WriteFile(raw, data, 0644); err != nil {
+		return "", fmt.Errorf("writeFramingPreview: %w", err)
+	}
+	img, err := framingPreview(raw)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(c.Target, FramingPreviewName)
+	fh, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("writeFramingPreview: %w", err)
+	}
+	if err := png.Encode(fh, img); err != nil {
+		fh.Close()
+		return "", fmt.Errorf("writeFramingPreview: %w", err)
+	}
+	return path, fh.Close()
+}
@@ -0,0 +1,106 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	/* ProbePrefix starts names of temporary files created while probing target filesystem */
+	ProbePrefix = ".astro-probe-"
+	/* ProbeMaxNameLength is the longest file name probed, which is the limit of common filesystems */
+	ProbeMaxNameLength = 255
+)
+
+/* FSInfo describes file naming limits of a filesystem */
+type FSInfo struct {
+	MaxNameLength int
+	CaseSensitive bool
+}
+
+/* probeName creates and removes a file with the specified name, reporting whether it was stored unchanged */
+func probeName(dir, name string) bool {
+	path := filepath.Join(dir, name)
+	fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return false
+	}
+	fh.Close()
+	defer os.Remove(path)
+	/* detect silent truncation by looking for the exact name in directory listing */
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+/* probeFilesystem determines max file name length and case sensitivity of directory filesystem by creating test files */
+func probeFilesystem(dir string) (FSInfo, error) {
+	info := FSInfo{}
+	/* binary search of the longest name which is stored without truncation */
+	low, high := len(ProbePrefix), ProbeMaxNameLength
+	if !probeName(dir, ProbePrefix) {
+		return info, fmt.Errorf("probeFilesystem(%s): unable to create test file", dir)
+	}
+	for low < high {
+		length := (low + high + 1) / 2
+		if probeName(dir, ProbePrefix+strings.Repeat("x", length-len(ProbePrefix))) {
+			low = length
+		} else {
+			high = length - 1
+		}
+	}
+	info.MaxNameLength = low
+	/* filesystem is case sensitive when upper case name does not resolve to the lower case file */
+	path := filepath.Join(dir, ProbePrefix+"case")
+	fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return info, fmt.Errorf("probeFilesystem(%s): %w", dir, err)
+	}
+	fh.Close()
+	defer os.Remove(path)
+	_, err = os.Stat(filepath.Join(dir, strings.ToUpper(ProbePrefix+"case")))
+	info.CaseSensitive = os.IsNotExist(err)
+	return info, nil
+}
+
+/* sessionNames returns names of files expected to be written to target directory judging by files on the card */
+func (c *Camera) sessionNames() []string {
+	names := []string{RunningPreviewName}
+	for _, file := range c.Files {
+		names = append(names, file.Name)
+		if c.Sidecar {
+			names = append(names, sidecarPath(file.Name))
+		}
+	}
+	return names
+}
+
+/* checkFilesystem warns when target filesystem can truncate or mix up names of files written during session */
+func (c *Camera) checkFilesystem(names []string) {
+	info, err := probeFilesystem(c.Target)
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+		return
+	}
+	folded := make(map[string]string)
+	for _, name := range names {
+		if len(name) > info.MaxNameLength {
+			log.Printf("Warning: %s would be truncated, target filesystem supports names of up to %d characters\n", name, info.MaxNameLength)
+		}
+		/* names differing only in case overwrite each other on case insensitive filesystems */
+		if other, ok := folded[strings.ToLower(name)]; ok && other != name && !info.CaseSensitive {
+			log.Printf("Warning: %s and %s would overwrite each other, target filesystem is case insensitive\n", name, other)
+		}
+		folded[strings.ToLower(name)] = name
+	}
+}
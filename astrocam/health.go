@@ -0,0 +1,23 @@
+package astrocam
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+/* touchHealthFile updates modification time of the health file, so external watchdogs can detect a stalled session by its age */
+func (c *Camera) touchHealthFile() error {
+	if c.HealthFile == "" {
+		return nil
+	}
+	now := time.Now()
+	if err := os.Chtimes(c.HealthFile, now, now); err == nil {
+		return nil
+	}
+	fh, err := os.OpenFile(c.HealthFile, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("touchHealthFile: %w", err)
+	}
+	return fh.Close()
+}
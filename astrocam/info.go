@@ -0,0 +1,43 @@
+package astrocam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+/* Info describes camera state at session start */
+type Info struct {
+	Time          time.Time `json:"time"`
+	Camera        string    `json:"camera,omitempty"`
+	Model         string    `json:"model"`
+	Lens          string    `json:"lens"`
+	Files         int       `json:"files"`
+	Battery       string    `json:"battery"`
+	BatteryFrames int       `json:"battery_frames"`
+	ExternalPower bool      `json:"external_power"`
+}
+
+/* PrintInfo prints startup info either as human readable banner or as a single json object */
+func (c *Camera) PrintInfo() error {
+	if c.JSONInfo {
+		data, err := json.Marshal(c.Info)
+		if err != nil {
+			return fmt.Errorf("PrintInfo: %w", err)
+		}
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	fmt.Printf("Camera Model:  %s\n", c.Info.Model)
+	fmt.Printf("Lens Model:    %s\n", c.Info.Lens)
+	fmt.Printf("SD Card Files: %d\n", c.Info.Files)
+	if c.Info.ExternalPower {
+		fmt.Printf("Battery Level: %s (external power)\n\n", c.Info.Battery)
+	} else if c.Info.BatteryFrames >= 0 {
+		fmt.Printf("Battery Level: %s (about %d frames)\n\n", c.Info.Battery, c.Info.BatteryFrames)
+	} else {
+		fmt.Printf("Battery Level: %s\n\n", c.Info.Battery)
+	}
+	return nil
+}
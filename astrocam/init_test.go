@@ -0,0 +1,88 @@
+package astrocam
+
+import (
+	"errors"
+	"testing"
+)
+
+/* newInitBackend creates fake body supporting all settings Init requires */
+func newInitBackend() *fakeBackend {
+	backend := newFakeBackend(map[string]string{
+		"cameramodel":   "Canon EOS 6D",
+		"lensname":      "EF50mm f/1.8 STM",
+		"focusmode":     "One Shot",
+		"drivemode":     "Continuous",
+		"shutterspeed":  "1/100",
+		"iso":           "100",
+		"whitebalance":  "Auto",
+		"imageformat":   "RAW",
+		"aperture":      "4",
+		"capturetarget": "Internal RAM",
+	})
+	backend.options["drivemode"] = []string{"Single", "Continuous"}
+	backend.options["shutterspeed"] = []string{"bulb", "30", "1/100"}
+	return backend
+}
+
+/* initFakeCamera initializes session connected to the fake backend */
+func initFakeCamera(t *testing.T, backend *fakeBackend) (*Camera, error) {
+	open := openCamera
+	openCamera = func(name string) (Backend, error) { return backend, nil }
+	t.Cleanup(func() { openCamera = open })
+	c := New()
+	c.Target = t.TempDir()
+	return c, c.Init("")
+}
+
+func TestInit(t *testing.T) {
+	c, err := initFakeCamera(t, newInitBackend())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Model != "Canon EOS 6D" || c.Lens != "EF50mm f/1.8 STM" {
+		t.Errorf("Init() read model %q and lens %q", c.Model, c.Lens)
+	}
+}
+
+func TestInitWithoutLens(t *testing.T) {
+	/* manual lenses do not report lens name and some bodies lack model name, binding gives nil widget for both */
+	for _, name := range []string{"lensname", "cameramodel"} {
+		t.Run(name, func(t *testing.T) {
+			backend := newInitBackend()
+			delete(backend.settings, name)
+			c, err := initFakeCamera(t, backend)
+			if err != nil {
+				t.Fatalf("Init() = %v, want nil", err)
+			}
+			info := map[string]string{"lensname": c.Lens, "cameramodel": c.Model}
+			if info[name] != UnknownValue {
+				t.Errorf("%s %q, want %q", name, info[name], UnknownValue)
+			}
+			if c.Info.Lens != c.Lens || c.Info.Model != c.Model {
+				t.Errorf("startup info %+v does not match lens %q and model %q", c.Info, c.Lens, c.Model)
+			}
+		})
+	}
+}
+
+func TestInitWithoutCaptureSetting(t *testing.T) {
+	/* settings affecting capture are still required */
+	backend := newInitBackend()
+	delete(backend.settings, "focusmode")
+	if _, err := initFakeCamera(t, backend); err == nil {
+		t.Fatal("Init() = nil, want error")
+	}
+}
+
+func TestInitNoMemoryCard(t *testing.T) {
+	backend := newInitBackend()
+	backend.noCard = true
+	_, err := initFakeCamera(t, backend)
+	if !errors.Is(err, ErrNoMemoryCard) {
+		t.Fatalf("Init() = %v, want ErrNoMemoryCard", err)
+	}
+	/* camera is left untouched when there is nothing to capture to */
+	if len(backend.sets) != 0 {
+		t.Errorf("Init() changed settings %v", backend.sets)
+	}
+}
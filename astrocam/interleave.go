@@ -0,0 +1,42 @@
+package astrocam
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+)
+
+/* InterleavedKind is the kind of frames interleaved with lights */
+const InterleavedKind = "darks"
+
+/* waitLensCap asks user to cover or uncover the lens unless confirmations are assumed */
+func (c *Camera) waitLensCap(action string) error {
+	if c.AssumeYes {
+		return nil
+	}
+	fmt.Printf("\n%s%s the lens and press Enter to continue... ", c.prefix(), action)
+	if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+		return fmt.Errorf("waitLensCap: %w", err)
+	}
+	return nil
+}
+
+/* captureInterleavedDark captures a dark frame into darks directory, tagged with the light frame it follows */
+func (c *Camera) captureInterleavedDark(ctx context.Context, follows int) error {
+	if err := c.waitLensCap("Cover"); err != nil {
+		return err
+	}
+	/* switch kind for the duration of a single frame so that routing and card timing follow darks, run and filter subfolders belong to lights */
+	kind, runDir, subDir, frameDir := c.Kind, c.runDir, c.subDir, c.frameDir
+	c.Kind, c.runDir, c.subDir = InterleavedKind, "", ""
+	c.follows = follows
+	c.darkFrames++
+	err := c.CaptureBulb(ctx, c.darkFrames)
+	c.Kind, c.runDir, c.subDir, c.frameDir = kind, runDir, subDir, frameDir
+	c.follows = 0
+	if err != nil {
+		return err
+	}
+	return c.waitLensCap("Uncover")
+}
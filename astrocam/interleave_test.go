@@ -0,0 +1,31 @@
+package astrocam
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaptureInterleavedDark(t *testing.T) {
+	c, backend := newCaptureCamera(t)
+	c.AssumeYes = true
+	/* lights of a plan block with filter go to object and filter subfolders of a new run */
+	c.runDir, c.subDir = "run-002", filepath.Join("M31", "Ha")
+	c.frameDir = c.targetPath(c.Summary.Start)
+	lights := c.frameDir
+	backend.capture = []string{"IMG_0002.CR2"}
+	if err := c.captureInterleavedDark(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(c.Target, InterleavedKind, "IMG_0002.CR2")); err != nil {
+		t.Errorf("dark not in darks directory: %v", err)
+	}
+	record := c.Summary.Records[0]
+	if record.Kind != InterleavedKind || record.Follows != 1 {
+		t.Errorf("recorded %s frame following %d, want darks following 1", record.Kind, record.Follows)
+	}
+	if c.Kind != "lights" || c.runDir != "run-002" || c.subDir != filepath.Join("M31", "Ha") || c.frameDir != lights {
+		t.Errorf("lights routing not restored: kind %s, run %s, subfolder %s, frame directory %s", c.Kind, c.runDir, c.subDir, c.frameDir)
+	}
+}
@@ -0,0 +1,70 @@
+package astrocam
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jonmol/gphoto2"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	/* LiveviewBoundary separates jpeg frames of the mjpeg stream */
+	LiveviewBoundary = "astroframe"
+	/* LiveviewInterval is the minimal time between two liveview frames */
+	LiveviewInterval = time.Millisecond * 100
+)
+
+/* liveviewLock allows a single liveview client at a time, the camera serves one preview request at once */
+var liveviewLock sync.Mutex
+
+/* streamLiveview writes camera preview frames as mjpeg stream until the client disconnects */
+func (c *Camera) streamLiveview(w http.ResponseWriter) {
+	liveviewLock.Lock()
+	defer liveviewLock.Unlock()
+	defer c.stopLiveview()
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+LiveviewBoundary)
+	flusher, _ := w.(http.Flusher)
+	buffer := new(bytes.Buffer)
+	for {
+		buffer.Reset()
+		if err := c.camera.CapturePreview(buffer); err != nil {
+			log.Printf("Warning: liveview: %v\n", cameraError("CapturePreview", err))
+			return
+		}
+		/* write fails once the client disconnects */
+		if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", LiveviewBoundary, buffer.Len()); err != nil {
+			return
+		}
+		if _, err := w.Write(append(buffer.Bytes(), '\r', '\n')); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		time.Sleep(LiveviewInterval)
+	}
+}
+
+/* stopLiveview closes the viewfinder so that the camera returns to normal state, bodies without the setting stop liveview by themselves */
+func (c *Camera) stopLiveview() {
+	setting, err := c.camera.GetSetting("viewfinder")
+	if err != nil || setting.Type() != gphoto2.WidgetToggle {
+		return
+	}
+	if err := setting.Set(false); err != nil {
+		log.Printf("Warning: unable to stop liveview: %v\n", err)
+	}
+}
+
+/* ServeLiveview serves liveview mjpeg stream on the specified address until the server fails */
+func (c *Camera) ServeLiveview(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		c.streamLiveview(w)
+	})
+	fmt.Printf("Serving liveview on %s, press Ctrl+C to stop\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
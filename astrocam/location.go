@@ -0,0 +1,116 @@
+package astrocam
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* GPSDTimeout is the maximal time to wait for a position fix from gpsd */
+const GPSDTimeout = time.Second * 10
+
+/* Location is a geographic position of the imaging rig */
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+/* String formats location as comma separated coordinates */
+func (l Location) String() string {
+	return fmt.Sprintf("%.6f,%.6f", l.Latitude, l.Longitude)
+}
+
+/* LocationSource provides current location of the imaging rig */
+type LocationSource interface {
+	Location() (Location, error)
+}
+
+/* FixedLocation is a location source with fixed coordinates */
+type FixedLocation Location
+
+/* Location returns fixed coordinates */
+func (f FixedLocation) Location() (Location, error) {
+	return Location(f), nil
+}
+
+/* GPSDLocation reads location from a gpsd daemon */
+type GPSDLocation struct {
+	Addr string
+}
+
+/* Location waits for a position fix reported by gpsd */
+func (g GPSDLocation) Location() (Location, error) {
+	conn, err := net.DialTimeout("tcp", g.Addr, GPSDTimeout)
+	if err != nil {
+		return Location{}, fmt.Errorf("gpsd: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(GPSDTimeout))
+	if _, err := fmt.Fprintf(conn, "?WATCH={\"enable\":true,\"json\":true}\n"); err != nil {
+		return Location{}, fmt.Errorf("gpsd: %w", err)
+	}
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var report struct {
+			Class string  `json:"class"`
+			Mode  int     `json:"mode"`
+			Lat   float64 `json:"lat"`
+			Lon   float64 `json:"lon"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			continue
+		}
+		/* time-position-velocity report with 2D or 3D fix */
+		if report.Class == "TPV" && report.Mode >= 2 {
+			return Location{Latitude: report.Lat, Longitude: report.Lon}, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Location{}, fmt.Errorf("gpsd: %w", err)
+	}
+	return Location{}, fmt.Errorf("gpsd: no position fix")
+}
+
+/* ParseLocationSource parses location specification: 'lat,lon', 'gpsd' or 'gpsd:host:port' */
+func ParseLocationSource(spec string) (LocationSource, error) {
+	if spec == "gpsd" {
+		return GPSDLocation{Addr: "localhost:2947"}, nil
+	}
+	if strings.HasPrefix(spec, "gpsd:") {
+		return GPSDLocation{Addr: strings.TrimPrefix(spec, "gpsd:")}, nil
+	}
+	parts := strings.Split(spec, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("bad location %q (must be 'lat,lon' or 'gpsd')", spec)
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return nil, fmt.Errorf("bad latitude in location %q", spec)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return nil, fmt.Errorf("bad longitude in location %q", spec)
+	}
+	return FixedLocation{Latitude: latitude, Longitude: longitude}, nil
+}
+
+/* updateLocation reads current location, last known location is kept on failure */
+func (c *Camera) updateLocation() {
+	if c.LocationSource == nil {
+		return
+	}
+	location, err := c.LocationSource.Location()
+	if err != nil {
+		log.Printf("Warning: location: %v\n", err)
+		return
+	}
+	c.Location = &location
+	if c.Summary.Location == nil {
+		c.Summary.Location = &location
+	}
+}
@@ -0,0 +1,53 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+/* LockFileName is the name of the lock file in target directory */
+const LockFileName = ".astro.lock"
+
+/* AcquireLock takes an advisory lock on target directory so that only one instance uses it */
+func AcquireLock(dir string) (release func(), err error) {
+	/* target of a new session does not exist yet */
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("acquireLock: %w", err)
+	}
+	path := filepath.Join(dir, LockFileName)
+	fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("acquireLock: %w", err)
+	}
+	/* lock is released by the kernel when the holder exits, so locks from crashed instances are never held */
+	if err := syscall.Flock(int(fh.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		data, _ := os.ReadFile(path)
+		fh.Close()
+		return nil, fmt.Errorf("acquireLock: %s is in use by another instance (pid %s)", dir, strings.TrimSpace(string(data)))
+	}
+	/* report stale lock file left over by a crashed instance */
+	data := make([]byte, 32)
+	if n, _ := fh.Read(data); n != 0 {
+		if pid := strings.TrimSpace(string(data[:n])); pid != strconv.Itoa(os.Getpid()) {
+			log.Printf("Removing stale lock of pid %s in %s\n", pid, dir)
+		}
+	}
+	if err := fh.Truncate(0); err != nil {
+		fh.Close()
+		return nil, fmt.Errorf("acquireLock: %w", err)
+	}
+	if _, err := fh.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		fh.Close()
+		return nil, fmt.Errorf("acquireLock: %w", err)
+	}
+	/* lock file is kept, removing it would let another instance lock a new file while the old one is still locked */
+	return func() {
+		fh.Truncate(0)
+		fh.Close()
+	}, nil
+}
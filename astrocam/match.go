@@ -0,0 +1,63 @@
+package astrocam
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+/* referenceExposure returns iso and exposure time of the first frame in a reference session directory, read from its sidecar or exif data */
+func referenceExposure(dir string) (iso string, exposure float64, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", 0, fmt.Errorf("referenceExposure: %w", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	/* sidecars hold exposure parameters of every file format */
+	for _, name := range names {
+		if filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var metadata FrameMetadata
+		if err := json.Unmarshal(data, &metadata); err == nil && metadata.ISO != "" {
+			return metadata.ISO, metadata.Exposure, nil
+		}
+	}
+	for _, name := range names {
+		if info, err := readExif(filepath.Join(dir, name)); err == nil && info.ISO != 0 {
+			return strconv.Itoa(info.ISO), info.ExposureTime.Seconds(), nil
+		}
+	}
+	return "", 0, fmt.Errorf("referenceExposure: no frames with exposure data in %s", dir)
+}
+
+/* CheckMatch warns when iso or duration differ from the referenced lights session */
+func (c *Camera) CheckMatch(dir string) {
+	iso, exposure, err := referenceExposure(dir)
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+		return
+	}
+	if iso != c.ISO {
+		log.Printf("Warning: ISO %s does not match ISO %s of frames in %s\n", c.ISO, iso, dir)
+	}
+	/* host timed exposures deviate slightly from the requested duration */
+	if c.Shutter == "bulb" && math.Abs(exposure-float64(c.Duration)) > 1 {
+		log.Printf("Warning: duration %ds does not match %.1fs exposure of frames in %s\n", c.Duration, exposure, dir)
+	}
+}
@@ -0,0 +1,80 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+/* CameraMetrics holds monitoring counters and gauges of a single camera */
+type CameraMetrics struct {
+	FramesCaptured int
+	FramesFailed   int
+	Battery        float64
+	Remaining      int
+	DownloadBytes  int64
+	Retries        int
+}
+
+/* Metrics exposes capture metrics of all cameras in prometheus text format */
+type Metrics struct {
+	mu      sync.Mutex
+	cameras map[string]*CameraMetrics
+}
+
+/* NewMetrics creates empty metrics registry */
+func NewMetrics() *Metrics {
+	return &Metrics{cameras: make(map[string]*CameraMetrics)}
+}
+
+/* Update modifies metrics of the labelled camera, it does nothing on nil metrics */
+func (m *Metrics) Update(label string, update func(*CameraMetrics)) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics, ok := m.cameras[label]
+	if !ok {
+		metrics = new(CameraMetrics)
+		m.cameras[label] = metrics
+	}
+	update(metrics)
+}
+
+/* ServeHTTP writes metrics in prometheus text exposition format */
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	labels := make([]string, 0, len(m.cameras))
+	for label := range m.cameras {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metric := func(name, kind, help string, value func(*CameraMetrics) float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, label := range labels {
+			fmt.Fprintf(w, "%s{camera=%q} %g\n", name, label, value(m.cameras[label]))
+		}
+	}
+	metric("astro_frames_captured_total", "counter", "Number of captured frames.", func(c *CameraMetrics) float64 { return float64(c.FramesCaptured) })
+	metric("astro_frames_failed_total", "counter", "Number of frames which failed to capture.", func(c *CameraMetrics) float64 { return float64(c.FramesFailed) })
+	metric("astro_battery_percent", "gauge", "Camera battery level in percent.", func(c *CameraMetrics) float64 { return c.Battery })
+	metric("astro_exposure_remaining_seconds", "gauge", "Seconds remaining of current exposure.", func(c *CameraMetrics) float64 { return float64(c.Remaining) })
+	metric("astro_download_bytes_total", "counter", "Number of bytes downloaded from the camera.", func(c *CameraMetrics) float64 { return float64(c.DownloadBytes) })
+	metric("astro_retries_total", "counter", "Number of retried camera operations.", func(c *CameraMetrics) float64 { return float64(c.Retries) })
+}
+
+/* ServeMetrics serves metrics endpoint on the specified address in background */
+func ServeMetrics(addr string, metrics *Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Warning: metrics endpoint: %v\n", err)
+		}
+	}()
+}
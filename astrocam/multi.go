@@ -0,0 +1,132 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ExitInterrupted is the exit status of a session aborted by interrupt */
+const ExitInterrupted = 3
+
+/* unsafeNameChars matches characters not allowed in camera target subfolder names */
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+/* Cloner is implemented by filter wheels, sinks and other components keeping their own state, each camera of a multi camera run gets its own clone */
+type Cloner interface {
+	Clone() interface{}
+}
+
+/* clone returns copy of camera configuration which shares no mutable state with the original */
+func (c *Camera) clone() *Camera {
+	camera := *c
+	camera.Explicit = make(map[string]bool, len(c.Explicit))
+	for name, explicit := range c.Explicit {
+		camera.Explicit[name] = explicit
+	}
+	camera.Files = append(CameraFiles(nil), c.Files...)
+	camera.Filters = append(FilterPlan(nil), c.Filters...)
+	camera.saved = append([]savedSetting(nil), c.saved...)
+	camera.stored = append([]string(nil), c.stored...)
+	if c.Plan != nil {
+		plan := *c.Plan
+		plan.Blocks = append([]Block(nil), c.Plan.Blocks...)
+		camera.Plan = &plan
+	}
+	if c.Location != nil {
+		location := *c.Location
+		camera.Location = &location
+	}
+	if cloner, ok := c.FilterWheel.(Cloner); ok {
+		camera.FilterWheel = cloner.Clone().(FilterWheel)
+	}
+	if cloner, ok := c.Sink.(Cloner); ok {
+		camera.Sink = cloner.Clone().(Sink)
+	}
+	return &camera
+}
+
+/* AbortSessions releases shutters of all connected cameras and restores their settings, e.g. before exit on repeated interrupt */
+func AbortSessions(cameras []*Camera) {
+	for _, camera := range cameras {
+		if camera.camera == nil {
+			continue
+		}
+		/* release button if camera is capturing a frame */
+		camera.ForceRelease()
+		camera.restoreSettings()
+	}
+}
+
+/* NewSessions returns sessions of all named cameras configured from template, a single camera uses template itself */
+func NewSessions(template *Camera, names []string) ([]*Camera, error) {
+	if len(names) <= 1 {
+		template.name = strings.Join(names, "")
+		return []*Camera{template}, nil
+	}
+	/* each camera downloads frames to its own target subfolder */
+	cameras := make([]*Camera, 0, len(names))
+	for i, name := range names {
+		camera := template.clone()
+		camera.name = name
+		camera.Label = name
+		/* offset exposure starts of cameras to avoid vibration cross-talk and power spikes */
+		camera.PretriggerDelay = template.PretriggerDelay * time.Duration(i)
+		camera.Target = filepath.Join(template.Target, unsafeNameChars.ReplaceAllString(name, "_"))
+		if err := os.MkdirAll(filepath.Join(camera.Target, camera.Kind), 0755); err != nil {
+			return nil, fmt.Errorf("NewSessions: %w", err)
+		}
+		cameras = append(cameras, camera)
+	}
+	return cameras, nil
+}
+
+/* RunSessions runs capture sessions of all cameras concurrently, cancelled context aborts running exposures */
+func RunSessions(ctx context.Context, cameras []*Camera) error {
+	/* failure of one camera does not abort sessions of the others */
+	errs := make([]error, len(cameras))
+	var wg sync.WaitGroup
+	for i := range cameras {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = cameras[i].Run(ctx, cameras[i].name)
+		}(i)
+	}
+	wg.Wait()
+	if len(cameras) == 1 {
+		if errs[0] != nil {
+			return errs[0]
+		}
+		cameras[0].Summary.Print()
+		return nil
+	}
+
+	/* aggregate session summaries */
+	failed := []string{}
+	total := 0
+	for i, camera := range cameras {
+		fmt.Printf("\nCamera %s:\n", camera.Label)
+		if errs[i] != nil {
+			fmt.Printf("  Failed: %v\n", errs[i])
+			failed = append(failed, camera.Label)
+			continue
+		}
+		camera.Summary.Print()
+		total += camera.Summary.Frames
+	}
+	fmt.Printf("\nTotal frames: %d from %d cameras\n", total, len(cameras)-len(failed))
+	if len(failed) != 0 {
+		/* interrupted sessions are reported as such regardless of which cameras were affected */
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("RunSessions: %w, failed cameras: %s", err, strings.Join(failed, ", "))
+		}
+		return fmt.Errorf("RunSessions: failed cameras: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package astrocam
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	/* PairsTogether keeps raw and jpeg files of an exposure together in kind directory */
+	PairsTogether = "together"
+	/* PairsSplit routes raw and jpeg files of an exposure to raw and jpeg subfolders of kind directory */
+	PairsSplit = "split"
+	/* RawDir is the subfolder of raw files of raw+jpeg pairs */
+	RawDir = "raw"
+	/* JPEGDir is the subfolder of jpeg files of raw+jpeg pairs */
+	JPEGDir = "jpeg"
+)
+
+/* checkPairLayout validates raw+jpeg pair layout option */
+func (c *Camera) checkPairLayout() error {
+	switch c.PairLayout {
+	case PairsTogether, PairsSplit:
+		return nil
+	}
+	return fmt.Errorf("Bad 'pairs' option: %s (must be one of '%s' or '%s')", c.PairLayout, PairsTogether, PairsSplit)
+}
+
+/* findPairs returns names of files which form raw+jpeg pairs, i.e. share base name with a file of the other type */
+func findPairs(files CameraFiles) map[string]bool {
+	raws := make(map[string]string)
+	jpegs := make(map[string]string)
+	for _, file := range files {
+		base := strings.TrimSuffix(file.Name, filepath.Ext(file.Name))
+		if isRaw(file.Name) {
+			raws[base] = file.Name
+		} else if isJPEG(file.Name) {
+			jpegs[base] = file.Name
+		}
+	}
+	pairs := make(map[string]bool)
+	for base, raw := range raws {
+		if jpeg, ok := jpegs[base]; ok {
+			pairs[raw] = true
+			pairs[jpeg] = true
+		}
+	}
+	return pairs
+}
+
+/* localName returns name of downloaded file relative to frame directory, routing paired files to their subfolders */
+func localName(name string, pairs map[string]bool, layout string) string {
+	if layout != PairsSplit || !pairs[name] {
+		return name
+	}
+	if isRaw(name) {
+		return filepath.Join(RawDir, name)
+	}
+	return filepath.Join(JPEGDir, name)
+}
+
+/* buildLocalPath returns local path of camera file downloaded to frame directory with the specified pair layout */
+func buildLocalPath(dir, name string, pairs map[string]bool, layout string) string {
+	return filepath.Join(dir, localName(name, pairs, layout))
+}
+
+/* uniquePath adds a numeric suffix to base name of path already taken, e.g. when camera file numbering wraps */
+func uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+}
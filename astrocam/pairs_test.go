@@ -0,0 +1,103 @@
+package astrocam
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindPairs(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  map[string]bool
+	}{
+		{"empty card", nil, map[string]bool{}},
+		{"raw only", []string{"IMG_0001.CR2", "IMG_0002.CR2"}, map[string]bool{}},
+		{"jpeg only", []string{"IMG_0001.JPG"}, map[string]bool{}},
+		{"pair", []string{"IMG_0001.CR2", "IMG_0001.JPG"}, map[string]bool{"IMG_0001.CR2": true, "IMG_0001.JPG": true}},
+		{"mixed", []string{"IMG_0001.CR2", "IMG_0001.JPG", "IMG_0002.CR2", "IMG_0003.JPG"}, map[string]bool{"IMG_0001.CR2": true, "IMG_0001.JPG": true}},
+		{"case of extension", []string{"DSC_0001.nef", "DSC_0001.jpeg"}, map[string]bool{"DSC_0001.nef": true, "DSC_0001.jpeg": true}},
+		{"other files", []string{"MVI_0001.MOV", "MVI_0001.THM"}, map[string]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend(nil)
+			for _, name := range tt.files {
+				backend.files[name] = []byte(name)
+			}
+			var files CameraFiles
+			if err := files.LoadCameraFiles(backend); err != nil {
+				t.Fatal(err)
+			}
+			if got := findPairs(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findPairs(%v) = %v, want %v", tt.files, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildLocalPath(t *testing.T) {
+	pairs := map[string]bool{"IMG_0001.CR2": true, "IMG_0001.JPG": true}
+	tests := []struct {
+		name   string
+		target string
+		kind   string
+		subDir string
+		file   string
+		layout string
+		want   string
+	}{
+		{"plain", "/data/m42", "lights", "", "IMG_0001.CR2", PairsTogether, "/data/m42/lights/IMG_0001.CR2"},
+		{"trailing slash", "/data/m42/", "lights", "", "IMG_0002.CR2", PairsTogether, "/data/m42/lights/IMG_0002.CR2"},
+		{"double trailing slash", "/data/m42//", "darks", "", "IMG_0002.CR2", PairsTogether, "/data/m42/darks/IMG_0002.CR2"},
+		{"relative target", "m42", "flats", "", "IMG_0003.JPG", PairsTogether, "m42/flats/IMG_0003.JPG"},
+		{"no extension", "/data/m42", "bias", "", "IMG_0004", PairsSplit, "/data/m42/bias/IMG_0004"},
+		{"kind subdirectory", "/data/m42", "lights", "Ha", "IMG_0005.CR2", PairsTogether, "/data/m42/lights/Ha/IMG_0005.CR2"},
+		{"pair together raw", "/data/m42", "lights", "", "IMG_0001.CR2", PairsTogether, "/data/m42/lights/IMG_0001.CR2"},
+		{"pair together jpeg", "/data/m42", "lights", "", "IMG_0001.JPG", PairsTogether, "/data/m42/lights/IMG_0001.JPG"},
+		{"pair split raw", "/data/m42", "lights", "", "IMG_0001.CR2", PairsSplit, "/data/m42/lights/raw/IMG_0001.CR2"},
+		{"pair split jpeg", "/data/m42/", "lights", "Ha", "IMG_0001.JPG", PairsSplit, "/data/m42/lights/Ha/jpeg/IMG_0001.JPG"},
+		{"unpaired split", "/data/m42", "lights", "", "IMG_0006.JPG", PairsSplit, "/data/m42/lights/IMG_0006.JPG"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Camera{Target: tt.target, Kind: tt.kind, subDir: tt.subDir}
+			got := buildLocalPath(c.targetPath(c.Summary.Start), tt.file, pairs, tt.layout)
+			if got != tt.want {
+				t.Errorf("buildLocalPath(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniquePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		path     string
+		want     string
+	}{
+		{"free", nil, "IMG_0001.CR2", "IMG_0001.CR2"},
+		{"taken", []string{"IMG_0001.CR2"}, "IMG_0001.CR2", "IMG_0001-1.CR2"},
+		{"taken twice", []string{"IMG_0001.CR2", "IMG_0001-1.CR2"}, "IMG_0001.CR2", "IMG_0001-2.CR2"},
+		{"gap", []string{"IMG_0001.CR2", "IMG_0001-2.CR2"}, "IMG_0001.CR2", "IMG_0001-1.CR2"},
+		{"no extension", []string{"IMG_0001"}, "IMG_0001", "IMG_0001-1"},
+		{"other extension taken", []string{"IMG_0001.JPG"}, "IMG_0001.CR2", "IMG_0001.CR2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := uniquePath(filepath.Join(dir, tt.path))
+			if want := filepath.Join(dir, tt.want); got != want {
+				t.Errorf("uniquePath(%q) = %q, want %q", tt.path, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package astrocam
+
+import (
+	"log"
+	"strings"
+)
+
+/* usesPictureStyle reports whether jpeg files or previews are used, picture style has no effect on raw files */
+func (c *Camera) usesPictureStyle() bool {
+	if strings.Contains(strings.ToUpper(c.ImageFormat), "JPEG") {
+		return true
+	}
+	return c.SanityCheck || c.RunningPreview > 0 || c.Kind != "lights"
+}
+
+/* setPictureStyle sets the requested picture style, prior value is restored on exit */
+func (c *Camera) setPictureStyle() error {
+	if c.PictureStyle == "" || !c.usesPictureStyle() {
+		return nil
+	}
+	style, err := c.findChoice("picturestyle", c.PictureStyle)
+	if err != nil {
+		return err
+	}
+	if err := c.rememberConfig("picturestyle", style); err != nil {
+		return err
+	}
+	/* confirm the body accepted the value */
+	if value, err := c.GetConfig("picturestyle"); err == nil && value != style {
+		log.Printf("Warning: picture style set to %s but camera reports %s\n", style, value)
+	}
+	return nil
+}
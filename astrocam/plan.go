@@ -0,0 +1,158 @@
+package astrocam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/* Block is a single step of capture plan */
+type Block struct {
+	Object   string    `json:"object"`
+	Filter   string    `json:"filter"`
+	Kind     string    `json:"kind"`
+	Shutter  string    `json:"shutter"`
+	ISO      string    `json:"iso"`
+	Duration int       `json:"duration"`
+	Frames   int       `json:"frames"`
+	Start    time.Time `json:"start"`
+}
+
+/* Plan is an ordered list of capture blocks executed in a single session */
+type Plan struct {
+	Blocks []Block `json:"blocks"`
+	/* Shutter and Duration are the command line values used by blocks without their own value and kind preset */
+	Shutter  string `json:"-"`
+	Duration int    `json:"-"`
+	/* Kind is the command line kind used until a block sets its own */
+	Kind string `json:"-"`
+}
+
+/* LoadPlan reads and validates json plan file */
+func LoadPlan(path string) (*Plan, error) {
+	/* yaml is not decoded, so it would fail with a confusing json syntax error */
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return nil, fmt.Errorf("LoadPlan(%s): yaml plans are not supported, use json", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPlan: %w", err)
+	}
+	plan := new(Plan)
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("LoadPlan(%s): %w", path, err)
+	}
+	if len(plan.Blocks) == 0 {
+		return nil, fmt.Errorf("LoadPlan(%s): plan has no blocks", path)
+	}
+	var errs ConfigErrors
+	for i, block := range plan.Blocks {
+		if _, ok := KindPresets[block.Kind]; block.Kind != "" && !ok {
+			errs = append(errs, fmt.Errorf("block %d: kind must be one of lights/darks/flats/bias", i+1))
+		}
+		if block.Frames <= 0 {
+			errs = append(errs, fmt.Errorf("block %d: frames must be > 0", i+1))
+		}
+		if block.Duration < 0 {
+			errs = append(errs, fmt.Errorf("block %d: duration must be >= 0", i+1))
+		}
+		if strings.ContainsAny(block.Object+block.Filter, `/\`) || block.Object == ".." || block.Filter == ".." {
+			errs = append(errs, fmt.Errorf("block %d: object and filter must not contain path separators", i+1))
+		}
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("LoadPlan(%s): %w", path, errs)
+	}
+	return plan, nil
+}
+
+/* blockDuration returns exposure duration of block of the specified kind, blocks without duration use kind preset or command line duration */
+func (p *Plan) blockDuration(block Block, kind string) int {
+	switch {
+	case block.Duration > 0:
+		return block.Duration
+	case KindPresets[kind].Duration != 0:
+		return KindPresets[kind].Duration
+	}
+	return p.Duration
+}
+
+/* ShootingTime returns total exposure time of the plan in seconds, it is known once the plan is used by a session */
+func (p *Plan) ShootingTime() (total int) {
+	kind := p.Kind
+	for _, block := range p.Blocks {
+		if block.Kind != "" {
+			kind = block.Kind
+		}
+		total += block.Frames * p.blockDuration(block, kind)
+	}
+	return total
+}
+
+/* applyBlock applies capture parameters of block, unset kind and iso keep previous values while shutter and duration fall back to kind preset and command line */
+func (c *Camera) applyBlock(block Block, p *Plan) {
+	if block.Kind != "" {
+		c.Kind = block.Kind
+	}
+	switch {
+	case block.Shutter != "":
+		c.Shutter = block.Shutter
+	case KindPresets[c.Kind].Shutter != "":
+		c.Shutter = KindPresets[c.Kind].Shutter
+	default:
+		c.Shutter = p.Shutter
+	}
+	if block.ISO != "" {
+		c.ISO = block.ISO
+	}
+	c.Duration = p.blockDuration(block, c.Kind)
+	c.Frames = block.Frames
+	c.Filter = block.Filter
+	/* outputs of each block go to kind/object/filter */
+	c.subDir = filepath.Join(block.Object, block.Filter)
+	c.runDir = ""
+}
+
+/* UsePlan makes the session capture blocks of the plan, the first block determines initial camera settings */
+func (c *Camera) UsePlan(p *Plan) {
+	p.Shutter, p.Duration, p.Kind = c.Shutter, c.Duration, c.Kind
+	c.Plan = p
+	c.applyBlock(p.Blocks[0], p)
+}
+
+/* runPlan captures all blocks of the plan in order, waiting for block start times and selecting filters */
+func (c *Camera) runPlan(ctx context.Context, p Plan) error {
+	for i, block := range p.Blocks {
+		if c.Stop.Stopped() {
+			break
+		}
+		if wait := time.Until(block.Start); !block.Start.IsZero() && wait > 0 {
+			fmt.Printf("\n%sWaiting %v for block %d to start at %s\n", c.prefix(), wait.Round(time.Second), i+1, block.Start.Format("15:04:05"))
+			if err := c.wait(ctx, wait); err != nil {
+				return fmt.Errorf("runPlan: %w", err)
+			}
+		}
+		filter := c.Filter
+		c.applyBlock(block, &p)
+		if err := c.PrepareTarget(); err != nil {
+			return err
+		}
+		if err := c.applyKindSettings(c.Kind); err != nil {
+			return err
+		}
+		if block.Filter != "" && block.Filter != filter {
+			if err := c.selectFilter(block.Filter); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("\n%sBlock %d: capturing %d %s to %s\n", c.prefix(), i+1, c.Frames, c.Kind, c.targetPath(time.Now()))
+		if err := c.CaptureLoop(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
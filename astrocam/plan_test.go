@@ -0,0 +1,38 @@
+package astrocam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		wantErr string
+	}{
+		{"json", "plan.json", `{"blocks": [{"object": "M31", "frames": 10}]}`, ""},
+		{"yaml", "plan.yaml", "blocks:\n  - object: M31\n    frames: 10\n", "not supported"},
+		{"yml", "plan.yml", "blocks:\n  - object: M31\n    frames: 10\n", "not supported"},
+		{"no blocks", "plan.json", `{"blocks": []}`, "no blocks"},
+		{"path in object", "plan.json", `{"blocks": [{"object": "../M31", "frames": 10}]}`, "path separators"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadPlan(path)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("LoadPlan() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("LoadPlan() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
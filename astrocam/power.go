@@ -0,0 +1,48 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+/* PowerSetting is a camera setting with preferred session friendly values in order of preference */
+type PowerSetting struct {
+	Name   string
+	Values []string
+}
+
+/* PowerSettings lists auto power off and image review settings of various camera bodies */
+var PowerSettings = []PowerSetting{
+	{Name: "autopoweroff", Values: []string{"Disable", "Off", "0"}},
+	{Name: "reviewtime", Values: []string{"None", "Off"}},
+	{Name: "imgreview", Values: []string{"Off", "None"}},
+}
+
+/* optimizePower disables auto power off and image review, prior values are restored on close */
+func (c *Camera) optimizePower() {
+	for _, setting := range PowerSettings {
+		/* bodies have only some of the settings */
+		if _, err := c.camera.GetSetting(setting.Name); errors.Is(err, ErrNotSupported) {
+			continue
+		} else if err != nil {
+			log.Printf("Warning: unable to optimize %s: %v\n", setting.Name, err)
+			continue
+		}
+		if err := c.applyPowerSetting(setting); err != nil {
+			log.Printf("Warning: unable to optimize %s: %v\n", setting.Name, err)
+		}
+	}
+}
+
+/* applyPowerSetting configures the first enumerated value of setting found in the camera */
+func (c *Camera) applyPowerSetting(setting PowerSetting) error {
+	for _, value := range setting.Values {
+		choice, err := c.findChoice(setting.Name, value)
+		if err != nil {
+			continue
+		}
+		return c.rememberConfig(setting.Name, choice)
+	}
+	return fmt.Errorf("no session friendly value available")
+}
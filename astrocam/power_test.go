@@ -0,0 +1,22 @@
+package astrocam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptimizePower(t *testing.T) {
+	/* body has image review but no auto power off setting */
+	c, backend := newFakeCamera(t, map[string]string{"reviewtime": "2 seconds"})
+	backend.options["reviewtime"] = []string{"None", "2 seconds", "4 seconds"}
+	c.optimizePower()
+	if want := []string{"reviewtime=None"}; !reflect.DeepEqual(backend.sets, want) {
+		t.Errorf("optimizePower() set %v, want %v", backend.sets, want)
+	}
+	if err := c.restoreSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if value := backend.settings["reviewtime"]; value != "2 seconds" {
+		t.Errorf("restored reviewtime %q, want 2 seconds", value)
+	}
+}
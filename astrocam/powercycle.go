@@ -0,0 +1,80 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+)
+
+/* PowerCycleEvent records an attempt to recover camera by power cycling it */
+type PowerCycleEvent struct {
+	Time      time.Time
+	Frame     int
+	Err       error
+	Recovered bool
+}
+
+/* needsPowerCycle reports whether capture failed because camera disconnected, which may clear by power cycling it */
+func needsPowerCycle(err error) bool {
+	return errors.Is(err, ErrDisconnected)
+}
+
+/* reconnectFiles power cycles a camera which failed during download and looks up files on the new connection, so they can be downloaded again */
+func (c *Camera) reconnectFiles(frame int, files CameraFiles, cause error) (CameraFiles, error) {
+	if err := c.powerCycle(frame, cause); err != nil {
+		return files, err
+	}
+	listed, err := c.listFiles()
+	if err != nil {
+		return files, err
+	}
+	relinked := CameraFiles{}
+	for _, file := range files {
+		found := false
+		for _, fresh := range *listed {
+			if fresh.Folder == file.Folder && fresh.Name == file.Name {
+				relinked = append(relinked, fresh)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return files, fmt.Errorf("reconnectFiles: %s/%s no longer on the card", file.Folder, file.Name)
+		}
+	}
+	return relinked, nil
+}
+
+/* powerCycle runs power cycle command, waits for the camera to boot and reinitializes it keeping files known to the session */
+func (c *Camera) powerCycle(frame int, cause error) error {
+	event := PowerCycleEvent{Time: time.Now(), Frame: frame, Err: cause}
+	defer func() {
+		c.Summary.PowerCycles = append(c.Summary.PowerCycles, event)
+	}()
+	log.Printf("%sPower cycling camera after I/O error at frame %d: %v\n", c.prefix(), frame, cause)
+	/* drop the broken connection, errors are expected here */
+	if c.camera != nil {
+		c.camera.Free()
+		c.camera = nil
+	}
+	cmd := exec.Command("sh", "-c", c.PowerCycleCmd)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("powerCycle(%s): %w", c.PowerCycleCmd, err)
+	}
+	time.Sleep(c.PowerCycleDelay)
+	/* files on the card which are not known yet belong to the frame being captured and must be downloaded */
+	files, checksums, saved := c.Files, c.checksums, c.saved
+	err := c.Init(c.name)
+	c.Files, c.checksums, c.saved = files, checksums, saved
+	if err != nil {
+		return fmt.Errorf("powerCycle(init): %w", err)
+	}
+	event.Recovered = true
+	log.Printf("%sCamera recovered after power cycle, retrying frame %d\n", c.prefix(), frame)
+	return nil
+}
@@ -0,0 +1,76 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/* RunDirFormat is the name format of numbered run subfolders of kind directory */
+const RunDirFormat = "run%03d"
+
+/* frameFiles returns names of frame files in directory, ignoring subdirectories, hidden and generated files */
+func frameFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		/* frames of split raw+jpeg pairs are in subfolders */
+		if entry.IsDir() && (name == RawDir || name == JPEGDir) {
+			files, err := frameFiles(filepath.Join(dir, name))
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				names = append(names, filepath.Join(name, file))
+			}
+			continue
+		}
+		if entry.IsDir() || strings.HasPrefix(name, ".") || name == RunningPreviewName || name == SweepManifestName {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+/* nextRunDir returns name of the first numbered run subfolder following existing ones */
+func nextRunDir(dir string) string {
+	for run := 1; ; run++ {
+		name := fmt.Sprintf(RunDirFormat, run)
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			return name
+		}
+	}
+}
+
+/* PrepareTarget refuses to mix frames of a new session with frames already present in kind directory, unless appending or starting a new run subfolder */
+func (c *Camera) PrepareTarget() error {
+	dir := c.targetPath(time.Now())
+	files, err := frameFiles(dir)
+	if err != nil {
+		return fmt.Errorf("PrepareTarget: %w", err)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	switch {
+	case c.NewRun:
+		c.runDir = nextRunDir(dir)
+		fmt.Printf("%s%s already contains %d files, capturing to %s\n", c.prefix(), dir, len(files), c.runDir)
+	case c.Append:
+		log.Printf("Warning: %sappending to %d files already in %s\n", c.prefix(), len(files), dir)
+	default:
+		return fmt.Errorf("PrepareTarget: %s already contains %d files (e.g. %s), use -append to add frames or -new-run to capture to a new run subfolder", dir, len(files), files[0])
+	}
+	return nil
+}
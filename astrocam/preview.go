@@ -0,0 +1,38 @@
+package astrocam
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+/* capturePreview captures a liveview preview frame which is not stored on the memory card */
+func (c *Camera) capturePreview() ([]byte, image.Image, error) {
+	buffer := new(bytes.Buffer)
+	if err := c.camera.CapturePreview(buffer); err != nil {
+		return nil, nil, fmt.Errorf("capturePreview: %w", err)
+	}
+	data := buffer.Bytes()
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("capturePreview(decode): %w", err)
+	}
+	return data, img, nil
+}
+
+/* MeanBrightness returns average image luminance in range from 0 to 1 */
+func MeanBrightness(img image.Image) float64 {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return 0
+	}
+	var sum float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sum += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	return sum / float64(bounds.Dx()*bounds.Dy()) / 255
+}
@@ -0,0 +1,239 @@
+package astrocam
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* Config holds capture parameters shared by profiles and configuration files */
+type Config struct {
+	ISO      string  `json:"iso"`
+	Aperture float64 `json:"aperture"`
+	Shutter  string  `json:"shutter"`
+	Duration int     `json:"duration"`
+	Frames   int     `json:"frames"`
+	Kind     string  `json:"kind"`
+	Target   string  `json:"target"`
+	Keep     bool    `json:"keep"`
+}
+
+/* Config returns current effective capture configuration */
+func (c *Camera) Config() Config {
+	return Config{
+		ISO:      c.ISO,
+		Aperture: c.Aperture,
+		Shutter:  c.Shutter,
+		Duration: c.Duration,
+		Frames:   c.Frames,
+		Kind:     c.Kind,
+		Target:   c.Target,
+		Keep:     c.Keep,
+	}
+}
+
+/* applyConfig applies settings present in configuration, i.e. with known line, to all settings not explicitly set on the command line */
+func (c *Camera) applyConfig(cfg Config, lines map[string]int) {
+	/* apply value unless missing or overridden by flag, applied values take precedence over kind presets */
+	apply := func(name string, fn func()) {
+		if lines[name] != 0 && !c.Explicit[name] {
+			fn()
+			c.Explicit[name] = true
+		}
+	}
+	apply("iso", func() { c.ISO = cfg.ISO })
+	apply("aperture", func() { c.Aperture = cfg.Aperture })
+	apply("shutter", func() { c.Shutter = cfg.Shutter })
+	apply("duration", func() { c.Duration = cfg.Duration })
+	apply("frames", func() { c.Frames = cfg.Frames })
+	apply("kind", func() { c.Kind = cfg.Kind })
+	apply("target", func() { c.Target = cfg.Target })
+	apply("keep", func() { c.Keep = cfg.Keep })
+}
+
+/* FieldError is a problem with a single configuration setting, Line is 0 for settings not read from a file */
+type FieldError struct {
+	Field   string
+	Line    int
+	Message string
+}
+
+/* Error formats problem with line reference when it is known */
+func (e *FieldError) Error() string {
+	if e.Line == 0 {
+		return e.Field + " " + e.Message
+	}
+	return fmt.Sprintf("line %d: %s %s", e.Line, e.Field, e.Message)
+}
+
+/* ConfigErrors aggregates all problems found in a configuration, so they can be fixed in one pass */
+type ConfigErrors []error
+
+/* Error lists all configuration problems one per line */
+func (e ConfigErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return fmt.Sprintf("%d configuration problems:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+/* setLines refers problems with settings to their lines in configuration file */
+func (e ConfigErrors) setLines(lines map[string]int) {
+	for _, err := range e {
+		var field *FieldError
+		if errors.As(err, &field) {
+			field.Line = lines[field.Field]
+		}
+	}
+}
+
+/* validateConfig checks configuration values and returns ConfigErrors listing every problem found */
+func validateConfig(cfg *Config) error {
+	var errs ConfigErrors
+	problem := func(field, message string) {
+		errs = append(errs, &FieldError{Field: field, Message: message})
+	}
+	if cfg.Duration <= 0 {
+		problem("duration", "must be > 0")
+	}
+	if cfg.Frames < 0 {
+		problem("frames", "must be >= 0")
+	}
+	if _, ok := KindPresets[cfg.Kind]; !ok {
+		problem("kind", "must be one of lights/darks/flats/bias")
+	}
+	if strings.TrimSpace(cfg.ISO) == "" {
+		problem("iso", "must not be empty")
+	}
+	if strings.TrimSpace(cfg.Shutter) == "" {
+		problem("shutter", "must not be empty")
+	}
+	if cfg.Aperture < 0 {
+		problem("aperture", "must be >= 0")
+	}
+	if strings.TrimSpace(cfg.Target) == "" {
+		problem("target", "must not be empty")
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+/* lineAt returns line number of byte offset in data */
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+/* decodeConfig parses json configuration, unknown settings are rejected and decoding errors refer to the line of the problem */
+func decodeConfig(data []byte, cfg *Config) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(cfg)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("line %d: %w", lineAt(data, syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("line %d: %s must be %s, not %s", lineAt(data, typeErr.Offset), typeErr.Field, typeErr.Type, typeErr.Value)
+	case err != nil && strings.HasPrefix(err.Error(), "json: unknown field "):
+		/* the decoder reports unknown field by name only */
+		name, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		lines, _ := configKeys(data)
+		return &FieldError{Field: name, Line: lines[strings.ToLower(name)], Message: "is not a known setting"}
+	}
+	return err
+}
+
+/* configKeys returns lines of settings present in json configuration, names are lowercase as json field names match case insensitively */
+func configKeys(data []byte) (map[string]int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	lines := make(map[string]int)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("configKeys: bad key %v", token)
+		}
+		lines[strings.ToLower(key)] = lineAt(data, decoder.InputOffset())
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+/* profilePath returns path of the profile, names with a directory or .json extension are paths of profile files and other names are looked up in user config directory */
+func profilePath(name string) (string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) || strings.EqualFold(filepath.Ext(name), ".json") {
+		return name, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "astro", "profiles", name+".json"), nil
+}
+
+/* SaveProfile stores current effective configuration as the named profile or to the profile file at path */
+func (c *Camera) SaveProfile(name string) error {
+	path, err := profilePath(name)
+	if err != nil {
+		return fmt.Errorf("saveProfile: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("saveProfile: %w", err)
+	}
+	data, err := json.MarshalIndent(c.Config(), "", "\t")
+	if err != nil {
+		return fmt.Errorf("saveProfile: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("saveProfile: %w", err)
+	}
+	return nil
+}
+
+/* LoadProfile applies the named profile or profile file at path to all settings not explicitly set on the command line */
+func (c *Camera) LoadProfile(name string) error {
+	path, err := profilePath(name)
+	if err != nil {
+		return fmt.Errorf("loadProfile: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("loadProfile: %w", err)
+	}
+	cfg := c.Config()
+	if err := decodeConfig(data, &cfg); err != nil {
+		return fmt.Errorf("loadProfile(%s): %w", path, err)
+	}
+	lines, err := configKeys(data)
+	if err != nil {
+		return fmt.Errorf("loadProfile(%s): %w", path, err)
+	}
+	if err := validateConfig(&cfg); err != nil {
+		err.(ConfigErrors).setLines(lines)
+		return fmt.Errorf("loadProfile(%s): %w", path, err)
+	}
+	/* only settings found in the profile override kind presets */
+	c.applyConfig(cfg, lines)
+	return nil
+}
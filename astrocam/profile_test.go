@@ -0,0 +1,134 @@
+package astrocam
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/* validConfig returns configuration passing all validation rules */
+func validConfig() Config {
+	return Config{ISO: "800", Aperture: 2.8, Shutter: "bulb", Duration: 60, Frames: 10, Kind: "lights", Target: "/tmp/target"}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		fields []string
+	}{
+		{"valid", func(cfg *Config) {}, nil},
+		{"zero duration", func(cfg *Config) { cfg.Duration = 0 }, []string{"duration"}},
+		{"negative duration", func(cfg *Config) { cfg.Duration = -1 }, []string{"duration"}},
+		{"negative frames", func(cfg *Config) { cfg.Frames = -1 }, []string{"frames"}},
+		{"unlimited frames", func(cfg *Config) { cfg.Frames = 0 }, nil},
+		{"unknown kind", func(cfg *Config) { cfg.Kind = "light" }, []string{"kind"}},
+		{"empty iso", func(cfg *Config) { cfg.ISO = " " }, []string{"iso"}},
+		{"empty shutter", func(cfg *Config) { cfg.Shutter = "" }, []string{"shutter"}},
+		{"negative aperture", func(cfg *Config) { cfg.Aperture = -2.8 }, []string{"aperture"}},
+		{"empty target", func(cfg *Config) { cfg.Target = "" }, []string{"target"}},
+		{"all problems at once", func(cfg *Config) { cfg.Duration, cfg.Kind, cfg.Target = 0, "", "" }, []string{"duration", "kind", "target"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+			err := validateConfig(&cfg)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("validateConfig() = %v, want nil", err)
+				}
+				return
+			}
+			var errs ConfigErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("validateConfig() = %v, want ConfigErrors", err)
+			}
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("validateConfig() = %v, want problems with %v", err, tt.fields)
+			}
+			for i, field := range tt.fields {
+				var fieldErr *FieldError
+				if !errors.As(errs[i], &fieldErr) || fieldErr.Field != field {
+					t.Errorf("problem %d = %v, want problem with %s", i, errs[i], field)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"valid", "{\n\t\"iso\": \"1600\",\n\t\"duration\": 120\n}\n", ""},
+		{"syntax error", "{\n\t\"iso\": \"1600\",\n\t\"duration\": 120,\n}\n", "line 4: "},
+		{"type error", "{\n\t\"iso\": \"1600\",\n\t\"duration\": \"120\"\n}\n", "line 3: duration must be int, not string"},
+		{"unknown setting", "{\n\t\"iso\": \"1600\",\n\t\"durtion\": 120\n}\n", "line 3: durtion is not a known setting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			err := decodeConfig([]byte(tt.data), &cfg)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("decodeConfig() = %v, want nil", err)
+				}
+				if cfg.ISO != "1600" || cfg.Duration != 120 {
+					t.Errorf("decodeConfig() decoded %+v", cfg)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("decodeConfig() = %v, want error starting with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProfileLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	data := "{\n\t\"kind\": \"flats\",\n\t\"duration\": 0,\n\t\"target\": \"\"\n}\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := New()
+	err := c.LoadProfile(path)
+	if err == nil {
+		t.Fatal("LoadProfile() = nil, want error")
+	}
+	for _, want := range []string{"line 3: duration must be > 0", "line 4: target must not be empty"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadProfile() = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestLoadProfilePresentSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flats.json")
+	if err := os.WriteFile(path, []byte(`{"kind": "flats", "iso": "100"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := New()
+	c.Explicit["iso"] = true
+	if err := c.LoadProfile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Kind != "flats" || c.ISO != "800" {
+		t.Errorf("LoadProfile() set kind %q and iso %q, want flats and flag value 800", c.Kind, c.ISO)
+	}
+	for _, name := range []string{"shutter", "duration", "frames", "target"} {
+		if c.Explicit[name] {
+			t.Errorf("LoadProfile() marked %s explicit although profile does not set it", name)
+		}
+	}
+	/* kind preset applies to settings missing in the profile */
+	c.applyKindDefaults()
+	if c.Shutter != "1/50" || c.Duration != 1 {
+		t.Errorf("flats preset gave shutter %q and duration %d, want 1/50 and 1", c.Shutter, c.Duration)
+	}
+}
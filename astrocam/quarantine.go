@@ -0,0 +1,87 @@
+package astrocam
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/* JPEGTailSize is the number of bytes read from the end of jpeg file looking for end of image marker */
+const JPEGTailSize = 4096
+
+/* RejectedDir is the subfolder of kind directory holding frames which failed validation */
+const RejectedDir = "rejected"
+
+/* QuarantinedFrame is a downloaded file moved to rejected folder */
+type QuarantinedFrame struct {
+	Path   string
+	Reason string
+}
+
+/* validateFrame checks downloaded file for truncation and, with sanity check enabled, jpeg brightness matching frame kind */
+func (c *Camera) validateFrame(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("empty file")
+	}
+	if !isJPEG(path) {
+		return nil
+	}
+	if err := checkJPEGMarkers(path, info.Size()); err != nil {
+		return err
+	}
+	if c.SanityCheck {
+		return brightnessSanity(c.Kind, path)
+	}
+	return nil
+}
+
+/* checkJPEGMarkers checks jpeg starts with start of image and ends with end of image marker, reading only both ends of the file */
+func checkJPEGMarkers(path string, size int64) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	head := make([]byte, 2)
+	n, err := fh.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	head = head[:n]
+	/* some bodies pad jpeg with zeros after end of image marker */
+	tail := make([]byte, JPEGTailSize)
+	if size < JPEGTailSize {
+		tail = tail[:size]
+	}
+	if n, err = fh.ReadAt(tail, size-int64(len(tail))); err != nil && err != io.EOF {
+		return err
+	}
+	tail = tail[:n]
+	if !bytes.Equal(head, []byte{0xff, 0xd8}) || !bytes.HasSuffix(bytes.TrimRight(tail, "\x00"), []byte{0xff, 0xd9}) {
+		return fmt.Errorf("truncated or corrupted jpeg")
+	}
+	return nil
+}
+
+/* quarantine moves a rejected frame to rejected folder of frame directory together with a file describing the reason */
+func (c *Camera) quarantine(path, reason string) error {
+	dir := filepath.Join(c.frameDir, RejectedDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("quarantine: %w", err)
+	}
+	rejected := filepath.Join(dir, filepath.Base(path))
+	if err := os.Rename(path, rejected); err != nil {
+		return fmt.Errorf("quarantine: %w", err)
+	}
+	if err := os.WriteFile(rejected+".reason.txt", []byte(reason+"\n"), 0644); err != nil {
+		return fmt.Errorf("quarantine: %w", err)
+	}
+	c.Summary.Quarantined = append(c.Summary.Quarantined, QuarantinedFrame{Path: rejected, Reason: reason})
+	return nil
+}
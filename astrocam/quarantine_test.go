@@ -0,0 +1,41 @@
+package astrocam
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateFrame(t *testing.T) {
+	body := bytes.Repeat([]byte{0x55}, JPEGTailSize*2)
+	jpeg := append(append([]byte{0xff, 0xd8}, body...), 0xff, 0xd9)
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		wantErr bool
+	}{
+		{"raw", "IMG_0001.CR2", []byte("raw frame"), false},
+		{"empty raw", "IMG_0001.CR2", nil, true},
+		{"jpeg", "IMG_0001.JPG", jpeg, false},
+		{"padded jpeg", "IMG_0001.JPG", append(append([]byte{}, jpeg...), make([]byte, 100)...), false},
+		{"short jpeg", "IMG_0001.JPG", []byte{0xff, 0xd8, 0xff, 0xd9}, false},
+		{"truncated jpeg", "IMG_0001.JPG", jpeg[:len(jpeg)-100], true},
+		{"no start of image", "IMG_0001.JPG", jpeg[2:], true},
+		{"single byte jpeg", "IMG_0001.JPG", []byte{0xff}, true},
+		{"empty jpeg", "IMG_0001.JPG", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			c := New()
+			if err := c.validateFrame(path); (err != nil) != tt.wantErr {
+				t.Errorf("validateFrame() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package astrocam
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/* Ramp is a range of values changing smoothly from start to end across session frames */
+type Ramp struct {
+	Start float64
+	End   float64
+}
+
+/* String formats ramp as start:end */
+func (r *Ramp) String() string {
+	if !r.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%g:%g", r.Start, r.End)
+}
+
+/* Set parses ramp in start:end format, both values must be positive */
+func (r *Ramp) Set(value string) error {
+	start, end, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("bad ramp %q (must be start:end)", value)
+	}
+	var err error
+	if r.Start, err = strconv.ParseFloat(strings.TrimSpace(start), 64); err != nil || r.Start <= 0 {
+		return fmt.Errorf("bad ramp start %q (must be a positive number)", start)
+	}
+	if r.End, err = strconv.ParseFloat(strings.TrimSpace(end), 64); err != nil || r.End <= 0 {
+		return fmt.Errorf("bad ramp end %q (must be a positive number)", end)
+	}
+	return nil
+}
+
+/* Enabled reports whether ramp was set */
+func (r *Ramp) Enabled() bool {
+	return r.Start > 0
+}
+
+/* direction returns 1 for increasing, -1 for decreasing and 0 for constant ramp */
+func (r *Ramp) direction() int {
+	switch {
+	case r.End > r.Start:
+		return 1
+	case r.End < r.Start:
+		return -1
+	}
+	return 0
+}
+
+/* At returns value of frame, interpolation is geometric so every frame changes exposure by the same number of stops */
+func (r *Ramp) At(frame, frames int) float64 {
+	if frames <= 1 {
+		return r.Start
+	}
+	return r.Start * math.Pow(r.End/r.Start, float64(frame)/float64(frames-1))
+}
+
+/* checkRamps validates exposure ramp options */
+func (c *Camera) checkRamps() error {
+	if !c.DurationRamp.Enabled() && !c.ISORamp.Enabled() {
+		return nil
+	}
+	if c.Frames == 0 {
+		return fmt.Errorf("Exposure ramps require -frames")
+	}
+	if c.Sweep {
+		return fmt.Errorf("Exposure ramps can not be used with -sweep")
+	}
+	if c.DurationRamp.Enabled() && c.Shutter != "bulb" {
+		return fmt.Errorf("Option -ramp-duration requires -shutter=bulb")
+	}
+	/* day to night brightens both, night to day darkens both */
+	if c.DurationRamp.Enabled() && c.ISORamp.Enabled() && c.DurationRamp.direction()*c.ISORamp.direction() < 0 {
+		return fmt.Errorf("Options -ramp-duration and -ramp-iso must ramp in the same direction")
+	}
+	return nil
+}
+
+/* nearestISO returns enumerated iso choice closest to requested value in stops */
+func nearestISO(req float64, choices []string) (string, error) {
+	best := ""
+	bestDiff := math.Inf(1)
+	for _, choice := range choices {
+		value, err := strconv.ParseFloat(choice, 64)
+		if err != nil || value <= 0 {
+			/* skip non numeric choices such as "Auto" */
+			continue
+		}
+		if diff := math.Abs(math.Log2(value / req)); diff < bestDiff {
+			best, bestDiff = choice, diff
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("nearestISO: no numeric iso choices (choices: %s)", strings.Join(choices, ", "))
+	}
+	return best, nil
+}
+
+/* applyRamps sets duration and iso of frame interpolated between ramp start and end values */
+func (c *Camera) applyRamps(frame int) error {
+	if c.DurationRamp.Enabled() {
+		c.Duration = int(math.Max(1, math.Round(c.DurationRamp.At(frame, c.Frames))))
+	}
+	if !c.ISORamp.Enabled() {
+		return nil
+	}
+	setting, err := c.camera.GetSetting("iso")
+	if err != nil {
+		return cameraError("applyRamps(iso)", err)
+	}
+	choices, err := setting.Options()
+	if err != nil {
+		return cameraError("applyRamps(iso)", err)
+	}
+	iso, err := nearestISO(c.ISORamp.At(frame, c.Frames), choices)
+	if err != nil {
+		return err
+	}
+	if iso == c.ISO {
+		return nil
+	}
+	if err := c.SetConfig("iso", iso); err != nil {
+		return fmt.Errorf("applyRamps(iso): %w", err)
+	}
+	c.ISO = iso
+	return nil
+}
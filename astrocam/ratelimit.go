@@ -0,0 +1,83 @@
+package astrocam
+
+import (
+	"io"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+/* NetworkFilesystems lists statfs magic numbers of network filesystems */
+var NetworkFilesystems = map[int64]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+}
+
+/* isNetworkFilesystem reports whether dir is stored on a network filesystem */
+func isNetworkFilesystem(dir string) bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return false
+	}
+	_, ok := NetworkFilesystems[int64(stat.Type)]
+	return ok
+}
+
+/* rateLimitedWriter is a token bucket limiting average throughput of the underlying writer to rate bytes per second */
+type rateLimitedWriter struct {
+	w      io.Writer
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+/* newRateLimitedWriter returns writer limited to rate bytes per second, bucket holds at most one second of data */
+func newRateLimitedWriter(w io.Writer, rate int64) *rateLimitedWriter {
+	return &rateLimitedWriter{w: w, rate: rate, tokens: float64(rate), last: time.Now()}
+}
+
+/* refill adds tokens for time passed since the last refill */
+func (rw *rateLimitedWriter) refill() {
+	now := time.Now()
+	rw.tokens += now.Sub(rw.last).Seconds() * float64(rw.rate)
+	if rw.tokens > float64(rw.rate) {
+		rw.tokens = float64(rw.rate)
+	}
+	rw.last = now
+}
+
+/* Write writes data in chunks no larger than the bucket, so even a very low rate only delays large writes */
+func (rw *rateLimitedWriter) Write(p []byte) (written int, err error) {
+	for written < len(p) {
+		chunk := len(p) - written
+		if int64(chunk) > rw.rate {
+			chunk = int(rw.rate)
+		}
+		rw.refill()
+		if missing := float64(chunk) - rw.tokens; missing > 0 {
+			time.Sleep(time.Duration(missing / float64(rw.rate) * float64(time.Second)))
+			rw.refill()
+		}
+		n, err := rw.w.Write(p[written : written+chunk])
+		written += n
+		rw.tokens -= float64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+/* downloadRate returns download rate limit in bytes per second for local path or 0 when downloads are not limited */
+func (c *Camera) downloadRate(path string) int64 {
+	if c.RateLimit <= 0 {
+		return 0
+	}
+	if !c.RateLimitLocal && !isNetworkFilesystem(filepath.Dir(path)) {
+		return 0
+	}
+	return c.RateLimit
+}
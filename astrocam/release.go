@@ -0,0 +1,132 @@
+package astrocam
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/* ReleaseState is a state of the camera remote release button */
+type ReleaseState string
+
+const (
+	ReleaseImmediate ReleaseState = "Immediate"
+	ReleasePressHalf ReleaseState = "Press Half"
+	ReleasePressFull ReleaseState = "Press Full"
+	ReleaseHalf      ReleaseState = "Release Half"
+	ReleaseFull      ReleaseState = "Release Full"
+)
+
+/* ReleaseStates lists all remote release states known to work with eosremoterelease */
+var ReleaseStates = []ReleaseState{
+	ReleaseImmediate,
+	ReleasePressHalf,
+	ReleasePressFull,
+	ReleaseHalf,
+	ReleaseFull,
+}
+
+/* MaxMirrorPrefire is the longest delay accepted between mirror prefire and exposure */
+const MaxMirrorPrefire = time.Second * 30
+
+/* ReleaseSequence is an ordered list of release states sent to end an exposure */
+type ReleaseSequence []ReleaseState
+
+/* String formats release sequence as a comma separated list */
+func (r *ReleaseSequence) String() string {
+	states := make([]string, 0, len(*r))
+	for _, state := range *r {
+		states = append(states, string(state))
+	}
+	return strings.Join(states, ",")
+}
+
+/* Set parses a comma separated list of release states */
+func (r *ReleaseSequence) Set(value string) error {
+	sequence := ReleaseSequence{}
+	for _, item := range strings.Split(value, ",") {
+		state, err := ParseReleaseState(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		sequence = append(sequence, state)
+	}
+	*r = sequence
+	return nil
+}
+
+/* ParseReleaseState validates release state name */
+func ParseReleaseState(name string) (ReleaseState, error) {
+	for _, state := range ReleaseStates {
+		if strings.EqualFold(name, string(state)) {
+			return state, nil
+		}
+	}
+	return "", fmt.Errorf("unknown release state: %s", name)
+}
+
+/* triggers reports whether release state starts an exposure */
+func (s ReleaseState) triggers() bool {
+	return s == ReleaseImmediate || s == ReleasePressFull
+}
+
+/* setRelease switches remote release button to the specified state, transient usb errors are retried except for states starting an exposure */
+func (c *Camera) setRelease(state ReleaseState) error {
+	/* a timed out trigger may have been executed by the body, retrying it would expose a second frame */
+	attempts := c.Retries + 1
+	if state.triggers() {
+		attempts = 1
+	}
+	err := c.withRetry(attempts, func() error {
+		return c.SetConfig(EosRemoteRelease, string(state))
+	})
+	if err != nil {
+		return fmt.Errorf("setRelease(%s): %w", state, err)
+	}
+	return nil
+}
+
+/* Release sends the configured release sequence to end current exposure */
+func (c *Camera) Release() error {
+	for _, state := range c.ReleaseSequence {
+		if err := c.setRelease(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* checkPrefire validates mirror prefire options */
+func (c *Camera) checkPrefire() error {
+	if c.MirrorPrefire < 0 || c.MirrorPrefire > MaxMirrorPrefire {
+		return fmt.Errorf("Bad 'mirror-prefire' option: %v (must be between 0 and %v)", c.MirrorPrefire, MaxMirrorPrefire)
+	}
+	if c.MirrorPrefire > 0 && len(c.PrefireSequence) == 0 {
+		return fmt.Errorf("Option -mirror-prefire requires non-empty -prefire-sequence")
+	}
+	return nil
+}
+
+/* prefire sends the configured prefire sequence and waits for vibrations to settle before exposure is started */
+func (c *Camera) prefire() error {
+	if c.MirrorPrefire == 0 {
+		return nil
+	}
+	for _, state := range c.PrefireSequence {
+		if err := c.setRelease(state); err != nil {
+			return err
+		}
+	}
+	time.Sleep(c.MirrorPrefire)
+	return nil
+}
+
+/* ForceRelease tries both half and full release regardless of errors without retrying, returns the last error if any */
+func (c *Camera) ForceRelease() (err error) {
+	for _, state := range []ReleaseState{ReleaseHalf, ReleaseFull} {
+		if e := c.SetConfig(EosRemoteRelease, string(state)); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package astrocam
+
+import (
+	"errors"
+	"github.com/jonmol/gphoto2"
+	"testing"
+)
+
+func TestSetReleaseRetry(t *testing.T) {
+	tests := []struct {
+		state    ReleaseState
+		attempts int
+	}{
+		{ReleaseImmediate, 1},
+		{ReleasePressFull, 1},
+		{ReleasePressHalf, 2},
+		{ReleaseHalf, 2},
+		{ReleaseFull, 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			c, backend := newFakeCamera(t, map[string]string{EosRemoteRelease: "None"})
+			c.Retries = 1
+			backend.setErrors[EosRemoteRelease] = &gphoto2.GphotoError{Code: gphoto2.ErrorTimeout}
+			if err := c.setRelease(tt.state); !errors.Is(err, ErrTransient) {
+				t.Fatalf("setRelease() = %v, want ErrTransient", err)
+			}
+			if n := backend.attempts[EosRemoteRelease]; n != tt.attempts {
+				t.Errorf("sent %d times, want %d", n, tt.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package astrocam
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* reconstructState rebuilds state of a session from frames and their sidecar files in kind directory, exif data is used without sidecars */
+func reconstructState(dir string) (*SessionState, error) {
+	files, err := frameFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reconstructState: %w", err)
+	}
+	state := &SessionState{Dir: dir, Known: []string{}}
+	frames := make(map[string]bool)
+	latest, modified := "", time.Time{}
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if filepath.Ext(name) == ".json" {
+			var metadata FrameMetadata
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reconstructState: %w", err)
+			}
+			/* interleaved darks do not count as session frames */
+			if json.Unmarshal(data, &metadata) != nil || metadata.Frame == 0 || metadata.Follows != 0 {
+				continue
+			}
+			if metadata.Frame > state.Frames {
+				state.Frames = metadata.Frame
+				state.Time = metadata.End
+				state.Kind = metadata.Kind
+				state.ISO = metadata.ISO
+				state.Exposure = metadata.Exposure
+			}
+			continue
+		}
+		if !isRaw(name) && !isJPEG(name) {
+			continue
+		}
+		/* raw and jpeg files of a pair make a single frame */
+		frames[strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))] = true
+		state.Known = append(state.Known, filepath.Base(name))
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(modified) {
+			latest, modified = path, info.ModTime()
+		}
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("reconstructState: no frames found in %s", dir)
+	}
+	/* without sidecars frames are counted and exposure is read from the most recent one */
+	if state.Frames == 0 {
+		state.Frames = len(frames)
+		state.Time = modified
+		if info, err := readExif(latest); err == nil {
+			state.Exposure = info.ExposureTime.Seconds()
+			if info.ISO != 0 {
+				state.ISO = strconv.Itoa(info.ISO)
+			}
+		}
+	}
+	return state, nil
+}
+
+/* ResumeFromFrames continues a session with lost state file from frames already in kind directory using settings of the last frame */
+func (c *Camera) ResumeFromFrames() error {
+	state, err := reconstructState(c.targetPath(time.Now()))
+	if err != nil {
+		return err
+	}
+	if c.Frames > 0 && state.Frames >= c.Frames {
+		return fmt.Errorf("resumeFromFrames: %d of %d frames already captured in %s", state.Frames, c.Frames, state.Dir)
+	}
+	/* explicit options take precedence over settings of existing frames */
+	if state.ISO != "" && !c.Explicit["iso"] {
+		c.ISO = state.ISO
+		c.Explicit["iso"] = true
+	}
+	if duration := int(math.Round(state.Exposure)); duration > 0 && !c.Explicit["duration"] {
+		c.Duration = duration
+		c.Explicit["duration"] = true
+	}
+	c.Append = true
+	c.resumed = state.Frames
+	fmt.Printf("Resuming %s session after frame %d captured at %s (iso %s, duration %ds).\n", c.Kind, state.Frames, state.Time.Format("15:04:05"), c.ISO, c.Duration)
+	return nil
+}
@@ -0,0 +1,61 @@
+package astrocam
+
+import (
+	"fmt"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	/* LightsBrightnessFloor is the minimal mean brightness of a lights preview with lens cap off */
+	LightsBrightnessFloor = 0.02
+	/* DarksBrightnessCeiling is the maximal mean brightness of a darks preview with lens cap on */
+	DarksBrightnessCeiling = 0.02
+)
+
+/* brightnessSanity verifies that preview image brightness matches frame kind */
+func brightnessSanity(kind string, path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	img, err := jpeg.Decode(fh)
+	if err != nil {
+		return fmt.Errorf("brightnessSanity(decode): %w", err)
+	}
+	brightness := MeanBrightness(img)
+	switch kind {
+	case "lights":
+		if brightness < LightsBrightnessFloor {
+			return fmt.Errorf("lights preview is black (brightness %.3f), is the lens cap on?", brightness)
+		}
+	case "darks":
+		if brightness > DarksBrightnessCeiling {
+			return fmt.Errorf("darks preview is not dark (brightness %.3f), is the lens cap off?", brightness)
+		}
+	}
+	return nil
+}
+
+/* CheckSanity captures a preview before the first frame and verifies its brightness */
+func (c *Camera) CheckSanity() error {
+	data, _, err := c.capturePreview()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("astro-sanity-%d.jpg", os.Getpid()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	if err := brightnessSanity(c.Kind, path); err != nil {
+		if c.SanityAbort {
+			return fmt.Errorf("CheckSanity: %w", err)
+		}
+		log.Printf("Warning: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package astrocam
+
+import (
+	"fmt"
+)
+
+/* SelfTestSetting is a camera setting exercised by self test */
+type SelfTestSetting struct {
+	Name  string
+	Write bool
+}
+
+/* SelfTestSettings lists every camera setting used by astro, writes are done only where safe */
+var SelfTestSettings = []SelfTestSetting{
+	{Name: "focusmode", Write: true},
+	{Name: "shutterspeed", Write: true},
+	{Name: "iso", Write: true},
+	{Name: "whitebalance", Write: true},
+	{Name: "imageformat", Write: true},
+	{Name: "aperture", Write: true},
+	{Name: "capturetarget", Write: true},
+	{Name: BatteryLevel, Write: false},
+	/* changing remote release state would trigger the shutter */
+	{Name: EosRemoteRelease, Write: false},
+}
+
+/* SelfTestResult is the outcome of self test of a single setting */
+type SelfTestResult struct {
+	Setting string
+	Value   string
+	Written bool
+	Err     error
+}
+
+/* SelfTestReport is the outcome of self test of all settings */
+type SelfTestReport []SelfTestResult
+
+/* Passed returns true if all settings passed self test */
+func (r SelfTestReport) Passed() bool {
+	for _, result := range r {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+/* Print displays self test report */
+func (r SelfTestReport) Print() {
+	for _, result := range r {
+		status := "PASS"
+		if result.Err != nil {
+			status = "FAIL"
+		}
+		mode := "read"
+		if result.Written {
+			mode = "read/write"
+		}
+		fmt.Printf("  %-4s %-16s %-10s %s", status, result.Setting, mode, result.Value)
+		if result.Err != nil {
+			fmt.Printf(" (%v)", result.Err)
+		}
+		fmt.Printf("\n")
+	}
+}
+
+/* testSetting reads setting and writes its current value back where safe, so camera state is unchanged */
+func (c *Camera) testSetting(setting SelfTestSetting) (result SelfTestResult) {
+	result.Setting = setting.Name
+	widget, err := c.camera.GetSetting(setting.Name)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	value, err := widget.Get()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Value = fmt.Sprintf("%v", value)
+	if !setting.Write {
+		return result
+	}
+	if err := widget.Set(value); err != nil {
+		result.Err = err
+		return result
+	}
+	result.Written = true
+	return result
+}
+
+/* RunSelfTest exercises every camera setting used by astro without capturing images */
+func (c *Camera) RunSelfTest() (SelfTestReport, error) {
+	if c.camera == nil {
+		return nil, fmt.Errorf("runSelfTest: camera not connected")
+	}
+	report := SelfTestReport{}
+	for _, setting := range SelfTestSettings {
+		report = append(report, c.testSetting(setting))
+	}
+	return report, nil
+}
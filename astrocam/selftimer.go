@@ -0,0 +1,58 @@
+package astrocam
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/* SelfTimerDelays lists self-timer delays in seconds accepted by -self-timer */
+var SelfTimerDelays = []int{2, 10}
+
+/* checkSelfTimer validates self-timer option */
+func (c *Camera) checkSelfTimer() error {
+	if c.SelfTimer == 0 {
+		return nil
+	}
+	if c.SkipDriveMode {
+		return fmt.Errorf("Option -self-timer can not be used with -skip-drivemode")
+	}
+	for _, delay := range SelfTimerDelays {
+		if c.SelfTimer == delay {
+			return nil
+		}
+	}
+	return fmt.Errorf("Bad 'self-timer' option: %d (must be 0, 2 or 10)", c.SelfTimer)
+}
+
+/* selfTimerDrive looks up drive mode choice of the self-timer with requested delay, e.g. 'Self-timer:2 sec' */
+func (c *Camera) selfTimerDrive() (string, error) {
+	setting, err := c.camera.GetSetting("drivemode")
+	if err != nil {
+		return "", err
+	}
+	choices, err := setting.Options()
+	if err != nil {
+		return "", err
+	}
+	delay := strconv.Itoa(c.SelfTimer)
+	notDigit := func(r rune) bool { return !unicode.IsDigit(r) }
+	for _, choice := range choices {
+		if !strings.Contains(strings.ToLower(choice), "timer") {
+			continue
+		}
+		for _, number := range strings.FieldsFunc(choice, notDigit) {
+			if number == delay {
+				return choice, nil
+			}
+		}
+	}
+	return "", &CameraError{Op: "selfTimerDrive", Class: ErrBadValue, Err: fmt.Errorf("no %ss self-timer drive mode (choices: %s)", delay, strings.Join(choices, ", "))}
+}
+
+/* selfTimerDelay returns time between release and exposure start */
+func (c *Camera) selfTimerDelay() time.Duration {
+	return time.Second * time.Duration(c.SelfTimer)
+}
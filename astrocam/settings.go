@@ -0,0 +1,86 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+/* UnknownValue is reported for informational settings not available on the camera */
+const UnknownValue = "unknown"
+
+/* savedSetting holds a camera setting value to be restored on exit */
+type savedSetting struct {
+	Name  string
+	Value string
+}
+
+/* GetConfig retrieves current value of a text camera setting */
+func (c *Camera) GetConfig(name string) (string, error) {
+	setting, err := c.camera.GetSetting(name)
+	if err != nil {
+		return "", cameraError("GetConfig("+name+")", err)
+	}
+	value, err := setting.Get()
+	if err != nil {
+		return "", cameraError("GetConfig("+name+")", err)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", &CameraError{Op: "GetConfig(" + name + ")", Class: ErrBadValue, Err: fmt.Errorf("setting is not a text value")}
+	}
+	return str, nil
+}
+
+/* infoConfig retrieves a purely informational setting, failures are logged and reported as unknown value */
+func (c *Camera) infoConfig(name string) string {
+	value, err := c.GetConfig(name)
+	if err != nil {
+		log.Printf("Warning: unable to read %s: %v\n", name, err)
+		return UnknownValue
+	}
+	return value
+}
+
+/* findChoice returns enumerated choice of camera setting matching value case insensitively */
+func (c *Camera) findChoice(name, value string) (string, error) {
+	setting, err := c.camera.GetSetting(name)
+	if err != nil {
+		return "", err
+	}
+	choices, err := setting.Options()
+	if err != nil {
+		return "", err
+	}
+	for _, choice := range choices {
+		if strings.EqualFold(choice, value) {
+			return choice, nil
+		}
+	}
+	return "", &CameraError{Op: "findChoice(" + name + ")", Class: ErrBadValue, Err: fmt.Errorf("bad value %q (choices: %s)", value, strings.Join(choices, ", "))}
+}
+
+/* rememberConfig configures camera setting and records its prior value for restoreSettings */
+func (c *Camera) rememberConfig(name, value string) error {
+	prior, err := c.GetConfig(name)
+	if err != nil {
+		return err
+	}
+	if err := c.SetConfig(name, value); err != nil {
+		return err
+	}
+	c.saved = append(c.saved, savedSetting{Name: name, Value: prior})
+	return nil
+}
+
+/* restoreSettings restores all remembered camera settings in reverse order */
+func (c *Camera) restoreSettings() (err error) {
+	for len(c.saved) != 0 {
+		setting := c.saved[len(c.saved)-1]
+		c.saved = c.saved[:len(c.saved)-1]
+		if e := c.SetConfig(setting.Name, setting.Value); e != nil {
+			err = fmt.Errorf("restoreSettings(%s): %w", setting.Name, e)
+		}
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package astrocam
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMissingSettings(t *testing.T) {
+	/* every setting is missing on the body, features degrade or fail with not supported error */
+	tests := []struct {
+		name    string
+		run     func(c *Camera) error
+		wantErr bool
+	}{
+		{"get config", func(c *Camera) error { _, err := c.GetConfig("iso"); return err }, true},
+		{"set config", func(c *Camera) error { return c.SetConfig("iso", "800") }, true},
+		{"info config", func(c *Camera) error { c.infoConfig("lensname"); return nil }, false},
+		{"bulb shutter", func(c *Camera) error { return c.setBulbShutter() }, false},
+		{"aperture", func(c *Camera) error { return c.setAperture() }, false},
+		{"internal bulb", func(c *Camera) error { c.detectInternalBulb(); return nil }, false},
+		{"power", func(c *Camera) error { c.optimizePower(); return nil }, false},
+		{"self timer", func(c *Camera) error { _, err := c.selfTimerDrive(); return err }, true},
+		{"liveview", func(c *Camera) error { c.stopLiveview(); return nil }, false},
+		{"iso ramp", func(c *Camera) error { c.ISORamp = Ramp{Start: 100, End: 800}; return c.applyRamps(0) }, true},
+		{"flats metering", func(c *Camera) error { return c.MeterFlats() }, true},
+		{"describe", func(c *Camera) error { _, err := c.DescribeSetting("iso"); return err }, true},
+		{"battery", func(c *Camera) error { c.readBattery(); return nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newFakeCamera(t, map[string]string{})
+			err := tt.run(c)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("= %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrNotSupported) {
+				t.Errorf("= %v, want ErrNotSupported", err)
+			}
+		})
+	}
+}
+
+func TestSelfTestMissingSettings(t *testing.T) {
+	c, _ := newFakeCamera(t, map[string]string{"iso": "800"})
+	report, err := c.RunSelfTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed() {
+		t.Error("self test passed on body missing settings")
+	}
+	for _, result := range report {
+		if missing := result.Setting != "iso"; missing != errors.Is(result.Err, ErrNotSupported) {
+			t.Errorf("%s: %v", result.Setting, result.Err)
+		}
+	}
+}
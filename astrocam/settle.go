@@ -0,0 +1,25 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+/* settle pauses for seconds between exposures so vibrations of the previous shutter actuation or mount move die down, displaying a countdown */
+func (c *Camera) settle(ctx context.Context, frame, seconds int) error {
+	/* countdown overwrites the whole status line of the previous frame */
+	width := len(c.Status(frame, 0))
+	for left := seconds; left > 0; left-- {
+		if c.TUI == nil {
+			fmt.Printf("%-*s\r", width, fmt.Sprintf("%sSettling %ds...", c.prefix(), left))
+		}
+		if err := c.wait(ctx, time.Second); err != nil {
+			return fmt.Errorf("settle: %w", err)
+		}
+		if c.Stop.Stopped() {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package astrocam
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+/* AvailableShots is the camera setting holding estimated number of frames fitting on the card */
+const AvailableShots = "availableshots"
+
+/* readAvailableShots reads estimated number of frames the card can still hold, bodies without the setting fall back to card storage estimate */
+func (c *Camera) readAvailableShots() (int, error) {
+	value, err := c.GetConfig(AvailableShots)
+	if errors.Is(err, ErrNotSupported) {
+		return c.cardFreeImages()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(value))
+}
+
+/* cardFreeImages returns number of free images estimated by card storage, zero count means storage gives no estimate */
+func (c *Camera) cardFreeImages() (int, error) {
+	storage, err := c.camera.ListFiles()
+	if err != nil {
+		return 0, cameraError("cardFreeImages", err)
+	}
+	free := 0
+	for _, device := range storage {
+		free += int(device.FreeImages)
+	}
+	if free == 0 {
+		return 0, fmt.Errorf("cardFreeImages: %w", ErrNotSupported)
+	}
+	return free, nil
+}
+
+/* checkAvailableShots warns once when the card is expected to fill before remaining frames are captured */
+func (c *Camera) checkAvailableShots(remaining int) {
+	if c.noShots || c.shotsWarned || remaining <= 0 {
+		return
+	}
+	shots, err := c.readAvailableShots()
+	if errors.Is(err, ErrNotSupported) {
+		/* neither setting nor storage estimate available on this camera, do not try again */
+		c.noShots = true
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: unable to read available shots: %v\n", err)
+		return
+	}
+	if shots < remaining {
+		log.Printf("Warning: %scard will fill before session completes, about %d of %d remaining frames fit\n", c.prefix(), shots, remaining)
+		c.shotsWarned = true
+	}
+}
@@ -0,0 +1,35 @@
+package astrocam
+
+import (
+	"testing"
+)
+
+func TestCheckAvailableShots(t *testing.T) {
+	tests := []struct {
+		name       string
+		shots      string
+		freeImages uint64
+		warned     bool
+		disabled   bool
+	}{
+		{"enough shots", "100", 0, false, false},
+		{"card fills", "5", 0, true, false},
+		{"storage estimate", "", 5, true, false},
+		{"enough storage", "", 100, false, false},
+		{"no estimate", "", 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := map[string]string{}
+			if tt.shots != "" {
+				settings[AvailableShots] = tt.shots
+			}
+			c, backend := newFakeCamera(t, settings)
+			backend.freeImages = tt.freeImages
+			c.checkAvailableShots(10)
+			if c.shotsWarned != tt.warned || c.noShots != tt.disabled {
+				t.Errorf("checkAvailableShots() warned %v, disabled %v, want %v and %v", c.shotsWarned, c.noShots, tt.warned, tt.disabled)
+			}
+		})
+	}
+}
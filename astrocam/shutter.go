@@ -0,0 +1,48 @@
+package astrocam
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const (
+	/* ShutterCounter is the camera setting holding shutter actuation count */
+	ShutterCounter = "shuttercounter"
+	/* ShutterRatingWarning is the fraction of rated shutter life above which a warning is printed */
+	ShutterRatingWarning = 0.9
+)
+
+/* readShutterCount reads shutter actuation count, bodies with electronic shutter often do not report it */
+func (c *Camera) readShutterCount() (int, bool) {
+	value, err := c.GetConfig(ShutterCounter)
+	if err != nil {
+		if !errors.Is(err, ErrNotSupported) {
+			log.Printf("Warning: unable to read %s: %v\n", ShutterCounter, err)
+		}
+		return 0, false
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
+
+/* checkShutterCount records shutter count at session start and warns when it approaches rated shutter life */
+func (c *Camera) checkShutterCount() {
+	if c.Summary.ShutterStart != 0 {
+		return
+	}
+	count, ok := c.readShutterCount()
+	if !ok {
+		log.Printf("%sShutter count: %s\n", c.prefix(), UnknownValue)
+		return
+	}
+	c.Summary.ShutterStart = count
+	log.Printf("%sShutter count: %d\n", c.prefix(), count)
+	if c.ShutterRating > 0 && float64(count) >= float64(c.ShutterRating)*ShutterRatingWarning {
+		log.Printf("Warning: %sshutter count %d is close to rated %d actuations\n", c.prefix(), count, c.ShutterRating)
+	}
+}
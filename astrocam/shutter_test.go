@@ -0,0 +1,33 @@
+package astrocam
+
+import (
+	"testing"
+)
+
+func TestCheckShutterCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		want     int
+	}{
+		{"mechanical shutter", map[string]string{ShutterCounter: "12345"}, 12345},
+		{"no counter", map[string]string{}, 0},
+		{"unreadable counter", map[string]string{ShutterCounter: "n/a"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newFakeCamera(t, tt.settings)
+			c.checkShutterCount()
+			if c.Summary.ShutterStart != tt.want {
+				t.Errorf("shutter count at start %d, want %d", c.Summary.ShutterStart, tt.want)
+			}
+			/* session end reads the count again only when it was available at start */
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if c.Summary.ShutterEnd != tt.want {
+				t.Errorf("shutter count at end %d, want %d", c.Summary.ShutterEnd, tt.want)
+			}
+		})
+	}
+}
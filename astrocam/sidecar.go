@@ -0,0 +1,62 @@
+package astrocam
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/* FrameMetadata is the content of a frame metadata sidecar file */
+type FrameMetadata struct {
+	Frame    int       `json:"frame"`
+	Kind     string    `json:"kind"`
+	Follows  int       `json:"follows,omitempty"`
+	Filter   string    `json:"filter,omitempty"`
+	Files    []string  `json:"files"`
+	Camera   string    `json:"camera"`
+	Lens     string    `json:"lens"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Shutter  string    `json:"shutter"`
+	Exposure float64   `json:"exposure"`
+	ISO      string    `json:"iso"`
+	Aperture float64   `json:"aperture"`
+	Battery  string    `json:"battery"`
+	Location *Location `json:"location,omitempty"`
+	Crop     *Crop     `json:"crop,omitempty"`
+}
+
+/* sidecarPath returns path of the sidecar file of the specified frame file */
+func sidecarPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+}
+
+/* writeSidecar writes metadata of captured frame next to its first downloaded file */
+func (c *Camera) writeSidecar(record FrameRecord) error {
+	metadata := FrameMetadata{
+		Frame:    record.Frame,
+		Kind:     record.Kind,
+		Follows:  record.Follows,
+		Filter:   record.Filter,
+		Files:    record.Files,
+		Camera:   c.Model,
+		Lens:     c.Lens,
+		Start:    record.Start,
+		End:      record.End,
+		Shutter:  c.Shutter,
+		Exposure: record.Actual.Seconds(),
+		ISO:      record.ISO,
+		Aperture: c.Aperture,
+		Battery:  c.Battery,
+		Location: record.Location,
+		Crop:     c.crop(),
+	}
+	data, err := json.MarshalIndent(metadata, "", "\t")
+	if err != nil {
+		return err
+	}
+	path := sidecarPath(filepath.Join(record.Dir, record.Files[0]))
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
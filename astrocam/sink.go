@@ -0,0 +1,86 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+/* Sink receives downloaded frames, e.g. for streaming them to remote storage */
+type Sink interface {
+	Store(path string) error
+}
+
+/* CommandSink stores frames by running a shell command with frame path as its $1 argument */
+type CommandSink struct {
+	Command string
+}
+
+/* Store runs sink command for the specified frame */
+func (s CommandSink) Store(path string) error {
+	cmd := exec.Command("sh", "-c", s.Command, "sh", path)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("CommandSink(%s): %w", path, err)
+	}
+	return nil
+}
+
+/* storeFrame sends downloaded frame to sink and remembers it as safe to remove locally */
+func (c *Camera) storeFrame(path string) {
+	if c.Sink == nil {
+		return
+	}
+	if err := c.Sink.Store(path); err != nil {
+		log.Printf("Warning: %v\n", err)
+		return
+	}
+	c.stored = append(c.stored, path)
+}
+
+/* diskSpace returns free and total bytes of the filesystem containing dir */
+func diskSpace(dir string) (free uint64, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
+
+/* reclaimSpace removes oldest frames already stored by the sink until dir has targetFree bytes available */
+func (c *Camera) reclaimSpace(dir string, targetFree uint64) error {
+	for {
+		free, _, err := diskSpace(dir)
+		if err != nil {
+			return fmt.Errorf("reclaimSpace: %w", err)
+		}
+		if free >= targetFree {
+			return nil
+		}
+		/* only frames confirmed written to the sink are removed */
+		if len(c.stored) == 0 {
+			return fmt.Errorf("reclaimSpace: %d bytes free in %s and no stored frames left to remove", free, dir)
+		}
+		path := c.stored[0]
+		c.stored = c.stored[1:]
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("reclaimSpace: %w", err)
+		}
+		log.Printf("Removed local copy of %s to reclaim space\n", path)
+	}
+}
+
+/* ensureFreeSpace reclaims space in kind directory when free space drops below minimal percentage */
+func (c *Camera) ensureFreeSpace() error {
+	if c.MinFreeSpace <= 0 {
+		return nil
+	}
+	dir := c.frameDir
+	_, total, err := diskSpace(dir)
+	if err != nil {
+		return fmt.Errorf("ensureFreeSpace: %w", err)
+	}
+	return c.reclaimSpace(dir, uint64(float64(total)*c.MinFreeSpace/100))
+}
@@ -0,0 +1,145 @@
+package astrocam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/* SessionStateName is the name of the state file of a running session in target directory */
+const SessionStateName = ".astro-session.json"
+
+/* SessionState describes progress of a running session, it is removed when the session completes */
+type SessionState struct {
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"`
+	Dir   string    `json:"dir"`
+	Pairs string    `json:"pairs"`
+	Known []string  `json:"known"`
+
+	Frames   int     `json:"frames,omitempty"`
+	ISO      string  `json:"iso,omitempty"`
+	Exposure float64 `json:"exposure,omitempty"`
+}
+
+/* statePath returns path of session state file */
+func (c *Camera) statePath() string {
+	return filepath.Join(c.Target, SessionStateName)
+}
+
+/* saveState records camera files accounted for so far, so that frames captured but not downloaded can be recovered */
+func (c *Camera) saveState() {
+	state := SessionState{Time: time.Now(), Kind: c.Kind, Dir: c.frameDir, Pairs: c.PairLayout, Known: []string{}}
+	/* files which failed to download or whose download is deferred are left for recovery */
+	pending := make(map[string]bool)
+	for _, failure := range c.Summary.Failed {
+		pending[failure.File] = true
+	}
+	for _, name := range c.Summary.Deferred {
+		pending[name] = true
+	}
+	for _, file := range c.Files {
+		if !pending[file.Name] {
+			state.Known = append(state.Known, file.Name)
+		}
+	}
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = os.WriteFile(c.statePath(), data, 0644)
+	}
+	if err != nil {
+		log.Printf("Warning: unable to save session state: %v\n", err)
+	}
+}
+
+/* loadState reads state of an interrupted session */
+func (c *Camera) loadState() (state SessionState, err error) {
+	data, err := os.ReadFile(c.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, fmt.Errorf("loadState: no interrupted session found in %s", c.Target)
+		}
+		return state, fmt.Errorf("loadState: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("loadState: %w", err)
+	}
+	return state, nil
+}
+
+/* RecoverPartial downloads frames captured by an interrupted session but not downloaded before it ended */
+func (c *Camera) RecoverPartial(ctx context.Context) error {
+	state, err := c.loadState()
+	if err != nil {
+		return err
+	}
+	recovered, err := c.downloadUnknown(ctx, state)
+	if err != nil {
+		return fmt.Errorf("recoverPartial: %w", err)
+	}
+	c.Summary.Recovered = append(c.Summary.Recovered, recovered...)
+	fmt.Printf("Recovered %d files of interrupted %s session to %s.\n", len(recovered), state.Kind, state.Dir)
+	return os.Remove(c.statePath())
+}
+
+/* downloadUnknown downloads camera files not known to session state to its frame directory and returns their local names */
+func (c *Camera) downloadUnknown(ctx context.Context, state SessionState) ([]string, error) {
+	known := make(map[string]bool)
+	for _, name := range state.Known {
+		known[name] = true
+	}
+	files, err := c.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	/* frames appeared on the card after the last recorded frame and not present locally */
+	c.Kind, c.frameDir, c.PairLayout = state.Kind, state.Dir, state.Pairs
+	c.checksums = make(map[string]string)
+	newFiles := CameraFiles{}
+	for _, file := range *files {
+		if known[file.Name] {
+			continue
+		}
+		newFiles = append(newFiles, file)
+	}
+	if err := os.MkdirAll(c.frameDir, 0755); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	downloaded := make(map[string]bool)
+	pairs := findPairs(newFiles)
+	for _, file := range newFiles {
+		/* a local file of the same name may be this frame downloaded before the interruption or an older frame after camera file numbering wrapped */
+		local := buildLocalPath(c.frameDir, file.Name, pairs, c.PairLayout)
+		path := uniquePath(local)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return names, err
+		}
+		if err := c.downloadFile(ctx, file, path); err != nil {
+			return names, err
+		}
+		if path != local {
+			if sum, err := fileChecksum(local); err == nil && sum == c.checksums[path] {
+				c.takeChecksum(path)
+				os.Remove(path)
+				downloaded[file.Name] = true
+				continue
+			}
+		}
+		name, _ := filepath.Rel(c.frameDir, path)
+		if err := c.appendChecksum(path); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+		downloaded[file.Name] = true
+		names = append(names, name)
+		fmt.Printf("Downloaded %s\n", path)
+	}
+	if err := c.deleteNewFiles(newFiles, downloaded); err != nil {
+		return names, err
+	}
+	return names, nil
+}
@@ -0,0 +1,112 @@
+package astrocam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+/* StatusQueueSize is the number of progress messages buffered for a slow status reader */
+const StatusQueueSize = 16
+
+/* Progress describes current state of frame capture */
+type Progress struct {
+	Time      time.Time `json:"time"`
+	Camera    string    `json:"camera,omitempty"`
+	Model     string    `json:"model"`
+	Lens      string    `json:"lens"`
+	Kind      string    `json:"kind"`
+	Frame     int       `json:"frame"`
+	Frames    int       `json:"frames"`
+	Duration  int       `json:"duration"`
+	Remaining int       `json:"remaining"`
+	Battery   string    `json:"battery"`
+}
+
+/* Progress returns current capture progress of the specified frame */
+func (c *Camera) Progress(frame int, seconds int) Progress {
+	return Progress{
+		Time:      time.Now(),
+		Camera:    c.Label,
+		Model:     c.Model,
+		Lens:      c.Lens,
+		Kind:      c.Kind,
+		Frame:     frame,
+		Frames:    c.Frames,
+		Duration:  c.Duration,
+		Remaining: seconds,
+		Battery:   c.Battery,
+	}
+}
+
+/* report publishes capture progress to status output and either dashboard or status line */
+func (c *Camera) report(frame int, seconds int) {
+	progress := c.Progress(frame, seconds)
+	c.StatusOutput.Send(progress)
+	c.Metrics.Update(c.Label, func(m *CameraMetrics) { m.Remaining = seconds })
+	if c.TUI != nil {
+		c.TUI.Update(progress)
+		return
+	}
+	fmt.Printf("%s\r", c.Status(frame, seconds))
+}
+
+/* StatusWriter writes progress as newline delimited json to a named pipe or unix socket */
+type StatusWriter struct {
+	path  string
+	queue chan Progress
+}
+
+/* NewStatusWriter creates status writer for the specified path and starts its writer loop */
+func NewStatusWriter(path string) *StatusWriter {
+	w := &StatusWriter{
+		path:  path,
+		queue: make(chan Progress, StatusQueueSize),
+	}
+	go w.loop()
+	return w
+}
+
+/* Send queues progress for writing, it is dropped if the reader is too slow */
+func (w *StatusWriter) Send(progress Progress) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.queue <- progress:
+	default:
+	}
+}
+
+/* open opens status output, named pipes are opened for writing and anything else is dialed as unix socket */
+func (w *StatusWriter) open() (io.WriteCloser, error) {
+	if info, err := os.Stat(w.path); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return os.OpenFile(w.path, os.O_WRONLY, 0)
+	}
+	return net.Dial("unix", w.path)
+}
+
+/* loop writes queued progress messages, reopening the output after failures */
+func (w *StatusWriter) loop() {
+	var out io.WriteCloser
+	for progress := range w.queue {
+		if out == nil {
+			var err error
+			if out, err = w.open(); err != nil {
+				out = nil
+				continue
+			}
+		}
+		data, err := json.Marshal(progress)
+		if err != nil {
+			continue
+		}
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			out.Close()
+			out = nil
+		}
+	}
+}
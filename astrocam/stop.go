@@ -0,0 +1,37 @@
+package astrocam
+
+import "sync"
+
+/* StopSignal requests capture sessions to end gracefully once the current frame is downloaded, sessions sharing it stop together */
+type StopSignal struct {
+	once sync.Once
+	done chan struct{}
+}
+
+/* NewStopSignal creates stop signal for a single run of capture sessions */
+func NewStopSignal() *StopSignal {
+	return &StopSignal{done: make(chan struct{})}
+}
+
+/* Stop requests sessions to stop, repeated requests do nothing */
+func (s *StopSignal) Stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+/* Done returns channel closed when stop is requested, it is never closed for nil signal */
+func (s *StopSignal) Done() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.done
+}
+
+/* Stopped reports whether stop was requested */
+func (s *StopSignal) Stopped() bool {
+	select {
+	case <-s.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,204 @@
+package astrocam
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+/* FrameRecord describes exposure of a single captured frame */
+type FrameRecord struct {
+	Frame     int
+	Kind      string
+	Follows   int
+	Filter    string
+	Start     time.Time
+	End       time.Time
+	Method    string
+	Requested time.Duration
+	Actual    time.Duration
+	ISO       string
+	Dir       string
+	Files     []string
+	Location  *Location
+}
+
+/* FailedDownload describes a camera file which could not be downloaded and was left on the card */
+type FailedDownload struct {
+	Time  time.Time
+	Frame int
+	File  string
+	Err   error
+}
+
+/* DriftWarningThreshold is the mean exposure time discrepancy above which a warning is printed */
+const DriftWarningThreshold = time.Millisecond * 500
+
+/* DriftStats describes discrepancy between requested and actual exposure times */
+type DriftStats struct {
+	Count  int
+	Mean   time.Duration
+	Min    time.Duration
+	Max    time.Duration
+	StdDev time.Duration
+}
+
+/* Drift computes exposure time discrepancy statistics of frames with known requested exposure */
+func (s *SessionSummary) Drift() (stats DriftStats) {
+	var sum, sumSq float64
+	for _, record := range s.Records {
+		if record.Requested == 0 || record.Actual == 0 {
+			continue
+		}
+		drift := record.Actual - record.Requested
+		if stats.Count == 0 || drift < stats.Min {
+			stats.Min = drift
+		}
+		if stats.Count == 0 || drift > stats.Max {
+			stats.Max = drift
+		}
+		sum += float64(drift)
+		sumSq += float64(drift) * float64(drift)
+		stats.Count++
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+	mean := sum / float64(stats.Count)
+	stats.Mean = time.Duration(mean)
+	stats.StdDev = time.Duration(math.Sqrt(math.Max(sumSq/float64(stats.Count)-mean*mean, 0)))
+	return stats
+}
+
+/* SessionSummary collects statistics of a capture session */
+type SessionSummary struct {
+	Start    time.Time
+	End      time.Time
+	Frames   int
+	Records  []FrameRecord
+	Refocus  []RefocusEvent
+	Battery  []BatteryReading
+	Location *Location
+	Crop     *Crop
+
+	PowerCycles []PowerCycleEvent
+	Pauses      []PauseEvent
+
+	ShutterStart int
+	ShutterEnd   int
+
+	Recovered   []string
+	Quarantined []QuarantinedFrame
+	Failed      []FailedDownload
+	Deferred    []string
+}
+
+/* Paused returns total time capture was paused */
+func (s *SessionSummary) Paused() (paused time.Duration) {
+	for _, pause := range s.Pauses {
+		paused += pause.End.Sub(pause.Start)
+	}
+	return paused
+}
+
+/* Print displays session summary */
+func (s *SessionSummary) Print() {
+	fmt.Printf("Session Summary:\n")
+	fmt.Printf("  Frames:   %d\n", s.Frames)
+	/* paused time is not part of the imaging session */
+	paused := s.Paused()
+	fmt.Printf("  Duration: %v\n", (s.End.Sub(s.Start) - paused).Round(time.Second))
+	if paused > 0 {
+		fmt.Printf("  Paused:   %v in %d pauses\n", paused.Round(time.Second), len(s.Pauses))
+	}
+	for _, pause := range s.Pauses {
+		fmt.Printf("  Paused before frame %d from %s to %s: %s\n", pause.Frame, pause.Start.Format("15:04:05"), pause.End.Format("15:04:05"), pause.Reason)
+	}
+	if len(s.Deferred) != 0 {
+		fmt.Printf("  Deferred downloads: %d files\n", len(s.Deferred))
+	}
+	if len(s.Recovered) != 0 {
+		fmt.Printf("  Recovered files of interrupted session: %d\n", len(s.Recovered))
+	}
+	if s.Location != nil {
+		fmt.Printf("  Location: %s\n", s.Location)
+	}
+	if s.Crop != nil {
+		fmt.Printf("  Crop:     %s\n", s.Crop)
+	}
+	/* count frames by directory when session was split across date folders */
+	dirs := []string{}
+	dirFrames := make(map[string]int)
+	for _, record := range s.Records {
+		if dirFrames[record.Dir] == 0 {
+			dirs = append(dirs, record.Dir)
+		}
+		dirFrames[record.Dir]++
+	}
+	if len(dirs) > 1 {
+		for _, dir := range dirs {
+			fmt.Printf("  Frames in %s: %d\n", dir, dirFrames[dir])
+		}
+	}
+	/* count frames by exposure method */
+	methods := make(map[string]int)
+	for _, record := range s.Records {
+		methods[record.Method]++
+	}
+	for _, method := range []string{ExposureHost, ExposureInternal} {
+		if methods[method] != 0 {
+			fmt.Printf("  Frames timed by %s: %d\n", method, methods[method])
+		}
+	}
+	/* exposure time drift report */
+	if drift := s.Drift(); drift.Count != 0 {
+		fmt.Printf(
+			"  Exposure drift: mean %v, min %v, max %v, stddev %v\n",
+			drift.Mean.Round(time.Millisecond),
+			drift.Min.Round(time.Millisecond),
+			drift.Max.Round(time.Millisecond),
+			drift.StdDev.Round(time.Millisecond),
+		)
+		if drift.Mean > DriftWarningThreshold || drift.Mean < -DriftWarningThreshold {
+			fmt.Printf("  Warning: sub-exposures deviate from requested duration by %v on average\n", drift.Mean.Round(time.Millisecond))
+		}
+	}
+	/* battery discharge trend */
+	if trend, ok := s.BatteryTrend(); ok {
+		fmt.Printf("  Battery:  %.0f%% -> %.0f%% (%.1f%% per hour)\n", trend.Start, trend.End, trend.PerHour)
+		if trend.Remaining > 0 {
+			fmt.Printf("  Battery would last about %v more at this rate\n", trend.Remaining.Round(time.Minute))
+		}
+	}
+	for _, failure := range s.Failed {
+		fmt.Printf("  Download failed at frame %d, %s left on the camera: %v\n", failure.Frame, failure.File, failure.Err)
+	}
+	for _, frame := range s.Quarantined {
+		fmt.Printf("  Rejected %s: %s\n", frame.Path, frame.Reason)
+	}
+	if s.ShutterStart != 0 && s.ShutterEnd != 0 {
+		fmt.Printf("  Shutter:  %d -> %d (%d actuations)\n", s.ShutterStart, s.ShutterEnd, s.ShutterEnd-s.ShutterStart)
+	}
+	for _, event := range s.PowerCycles {
+		status := "recovered"
+		if !event.Recovered {
+			status = "failed"
+		}
+		fmt.Printf("  Power cycle at frame %d at %s: %s (%v)\n", event.Frame, event.Time.Format("15:04:05"), status, event.Err)
+	}
+	for _, event := range s.Refocus {
+		status := "ok"
+		if event.Autofocus {
+			status = "autofocus"
+		} else if event.Drift > 0 {
+			status = fmt.Sprintf("drift %.1f%%", event.Drift)
+		}
+		fmt.Printf(
+			"  Focus check after frame %d at %s: score %.1f (%s)\n",
+			event.Frame,
+			event.Time.Format("15:04:05"),
+			event.Score,
+			status,
+		)
+	}
+}
@@ -0,0 +1,159 @@
+package astrocam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* SweepManifestName is the name of calibration library manifest in kind directory */
+const SweepManifestName = "manifest.json"
+
+/* ISOBracket is a list of iso values swept in calibration library mode */
+type ISOBracket []string
+
+/* String returns comma separated list of iso values */
+func (b *ISOBracket) String() string {
+	return strings.Join(*b, ",")
+}
+
+/* Set parses a comma separated list of iso values */
+func (b *ISOBracket) Set(value string) error {
+	bracket := ISOBracket{}
+	for _, item := range strings.Split(value, ",") {
+		iso := strings.TrimSpace(item)
+		if _, err := strconv.Atoi(iso); err != nil {
+			return fmt.Errorf("bad iso value %q (must be a number)", iso)
+		}
+		bracket = append(bracket, iso)
+	}
+	*b = bracket
+	return nil
+}
+
+/* DurationBracket is a list of exposure durations in seconds swept in calibration library mode */
+type DurationBracket []int
+
+/* String returns comma separated list of durations */
+func (b *DurationBracket) String() string {
+	items := make([]string, 0, len(*b))
+	for _, duration := range *b {
+		items = append(items, strconv.Itoa(duration))
+	}
+	return strings.Join(items, ",")
+}
+
+/* Set parses a comma separated list of durations in seconds */
+func (b *DurationBracket) Set(value string) error {
+	bracket := DurationBracket{}
+	for _, item := range strings.Split(value, ",") {
+		duration, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("bad duration %q (must be a positive number of seconds)", item)
+		}
+		bracket = append(bracket, duration)
+	}
+	*b = bracket
+	return nil
+}
+
+/* SweepEntry describes a set of calibration frames captured with a single iso and duration combination */
+type SweepEntry struct {
+	ISO      string   `json:"iso"`
+	Duration int      `json:"duration,omitempty"`
+	Shutter  string   `json:"shutter"`
+	Dir      string   `json:"dir"`
+	Frames   int      `json:"frames"`
+	Files    []string `json:"files"`
+}
+
+/* sweepCombos returns iso and duration lists of the sweep, falling back to single configured values */
+func (c *Camera) sweepCombos() (isos []string, durations []int) {
+	isos, durations = c.ISOBracket, c.Bracket
+	if len(isos) == 0 {
+		isos = []string{c.ISO}
+	}
+	if len(durations) == 0 {
+		durations = []int{c.Duration}
+	}
+	return isos, durations
+}
+
+/* CheckSweep validates calibration library sweep options and returns total exposure time in seconds */
+func (c *Camera) CheckSweep() (int, error) {
+	if c.Kind != "darks" && c.Kind != "bias" {
+		return 0, fmt.Errorf("Option -sweep requires -kind=darks or -kind=bias")
+	}
+	if len(c.Bracket) != 0 && c.Shutter != "bulb" {
+		return 0, fmt.Errorf("Option -bracket requires -shutter=bulb")
+	}
+	if c.Frames == 0 {
+		return 0, fmt.Errorf("Option -sweep requires -frames per combination")
+	}
+	isos, durations := c.sweepCombos()
+	total := 0
+	for _, duration := range durations {
+		total += duration * c.Frames * len(isos)
+	}
+	return total, nil
+}
+
+/* sweepDir returns name of subdirectory holding frames of the specified combination, e.g. iso800_120s */
+func (c *Camera) sweepDir(iso string, duration int) string {
+	if c.Shutter != "bulb" {
+		return fmt.Sprintf("iso%s_%s", iso, strings.ReplaceAll(c.Shutter, "/", "-"))
+	}
+	return fmt.Sprintf("iso%s_%ds", iso, duration)
+}
+
+/* writeManifest writes calibration library manifest to kind directory, next to combination subdirectories */
+func (c *Camera) writeManifest(entries []SweepEntry) error {
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(filepath.Dir(c.frameDir), SweepManifestName), append(data, '\n'), 0644)
+}
+
+/* SweepLoop captures frames of every iso and duration combination to separate subdirectories of kind directory */
+func (c *Camera) SweepLoop(ctx context.Context) error {
+	isos, durations := c.sweepCombos()
+	entries := []SweepEntry{}
+	for _, iso := range isos {
+		if err := c.SetConfig("iso", iso); err != nil {
+			return fmt.Errorf("SweepLoop(iso): %w", err)
+		}
+		c.ISO = iso
+		for _, duration := range durations {
+			c.Duration = duration
+			c.subDir = c.sweepDir(iso, duration)
+			records := len(c.Summary.Records)
+			fmt.Printf("\n%sCapturing %d frames to %s\n", c.prefix(), c.Frames, c.subDir)
+			if err := c.CaptureLoop(ctx); err != nil {
+				return err
+			}
+			entry := SweepEntry{ISO: iso, Shutter: c.Shutter, Dir: c.subDir}
+			if c.Shutter == "bulb" {
+				entry.Duration = duration
+			}
+			for _, record := range c.Summary.Records[records:] {
+				entry.Frames++
+				entry.Files = append(entry.Files, record.Files...)
+			}
+			/* manifest is rewritten after each combination so that interrupted sweeps stay indexed */
+			entries = append(entries, entry)
+			if err := c.writeManifest(entries); err != nil {
+				return fmt.Errorf("SweepLoop(manifest): %w", err)
+			}
+			/* interrupted sweeps stop after the combination of the last complete frame */
+			if c.Stop.Stopped() {
+				return nil
+			}
+		}
+	}
+	return nil
+}
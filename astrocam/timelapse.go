@@ -0,0 +1,53 @@
+package astrocam
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+/* TimelapseName is the file name of the assembled timelapse video in kind directory */
+const TimelapseName = "timelapse.mp4"
+
+/* assembleTimelapse invokes ffmpeg to assemble jpeg frames in dir into a video with the specified framerate */
+func (c *Camera) assembleTimelapse(dir string, fps int) error {
+	ffmpeg, err := exec.LookPath(c.FFmpeg)
+	if err != nil {
+		return fmt.Errorf("assembleTimelapse: %s not found, install ffmpeg or set -ffmpeg path; frames in %s are left intact", c.FFmpeg, dir)
+	}
+	/* make sure there are jpeg frames to assemble */
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("assembleTimelapse: %w", err)
+	}
+	frames := 0
+	for _, entry := range entries {
+		if !entry.IsDir() && isJPEG(entry.Name()) {
+			frames++
+		}
+	}
+	if frames == 0 {
+		return fmt.Errorf("assembleTimelapse: no jpeg frames in %s, timelapse requires jpeg capture", dir)
+	}
+	fmt.Printf("Assembling timelapse from %d frames... ", frames)
+	cmd := exec.Command(
+		ffmpeg,
+		"-y",
+		"-loglevel", "error",
+		"-framerate", strconv.Itoa(fps),
+		"-pattern_type", "glob",
+		"-i", filepath.Join(dir, "*.[jJ][pP][gG]"),
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		filepath.Join(dir, TimelapseName),
+	)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("Error!\n")
+		return fmt.Errorf("assembleTimelapse(ffmpeg): %w", err)
+	}
+	fmt.Printf("Done.\n")
+	return nil
+}
@@ -0,0 +1,167 @@
+package astrocam
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	/* DashboardLogLines is the number of recent log lines displayed by dashboard */
+	DashboardLogLines = 8
+	/* DashboardBarWidth is the width of dashboard progress bars */
+	DashboardBarWidth = 40
+)
+
+/* Dashboard is a full screen terminal dashboard of capture progress */
+type Dashboard struct {
+	mu       sync.Mutex
+	out      io.Writer
+	start    time.Time
+	progress map[string]Progress
+	last     map[string]string
+	logs     []string
+}
+
+/* NewDashboard creates dashboard rendering to the specified terminal */
+func NewDashboard(out io.Writer) *Dashboard {
+	fmt.Fprintf(out, "\x1b[?25l")
+	return &Dashboard{
+		out:      out,
+		start:    time.Now(),
+		progress: make(map[string]Progress),
+		last:     make(map[string]string),
+	}
+}
+
+/* Update displays current capture progress */
+func (d *Dashboard) Update(progress Progress) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.progress[progress.Camera] = progress
+	d.render()
+}
+
+/* FrameDone displays summary of the last captured frame */
+func (d *Dashboard) FrameDone(camera string, summary string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.last[camera] = summary
+	d.render()
+}
+
+/* Write adds log output to recent log lines, dashboard is used as log output */
+func (d *Dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		d.logs = append(d.logs, strings.TrimSpace(line))
+	}
+	if len(d.logs) > DashboardLogLines {
+		d.logs = d.logs[len(d.logs)-DashboardLogLines:]
+	}
+	d.render()
+	return len(p), nil
+}
+
+/* Close restores terminal cursor */
+func (d *Dashboard) Close() {
+	fmt.Fprintf(d.out, "\x1b[?25h\n")
+}
+
+/* bar renders progress bar of the specified fraction */
+func bar(fraction float64) string {
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction * DashboardBarWidth)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", DashboardBarWidth-filled) + "]"
+}
+
+/* batteryGauge renders battery level as a bar when it is reported in percent */
+func batteryGauge(level string) string {
+	percent, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(level), "%"))
+	if err != nil {
+		return level
+	}
+	return fmt.Sprintf("%s %d%%", bar(float64(percent)/100), percent)
+}
+
+/* render redraws the whole dashboard, must be called with lock held */
+func (d *Dashboard) render() {
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("\x1b[H\x1b[2J")
+	cameras := make([]string, 0, len(d.progress))
+	for camera := range d.progress {
+		cameras = append(cameras, camera)
+	}
+	sort.Strings(cameras)
+	for _, camera := range cameras {
+		p := d.progress[camera]
+		fmt.Fprintf(buffer, "%s%s / %s\n", strings.TrimSpace(p.Camera+" "), p.Model, p.Lens)
+		fmt.Fprintf(buffer, "  Battery  %s\n", batteryGauge(p.Battery))
+		if p.Duration > 0 {
+			fmt.Fprintf(buffer, "  Frame    %s %3ds left\n", bar(1-float64(p.Remaining)/float64(p.Duration)), p.Remaining)
+		}
+		if p.Frames > 0 {
+			/* estimate remaining time from average time per completed frame */
+			eta := "unknown"
+			if done := p.Frame - 1; done > 0 {
+				perFrame := time.Since(d.start) / time.Duration(done)
+				eta = (perFrame * time.Duration(p.Frames-done)).Round(time.Second).String()
+			}
+			fmt.Fprintf(buffer, "  Session  %s %s %d/%d, ETA %s\n", bar(float64(p.Frame-1)/float64(p.Frames)), p.Kind, p.Frame, p.Frames, eta)
+		} else {
+			fmt.Fprintf(buffer, "  Session  %s frame %d\n", p.Kind, p.Frame)
+		}
+		if last, ok := d.last[camera]; ok {
+			fmt.Fprintf(buffer, "  Last     %s\n", last)
+		}
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("Recent log:\n")
+	for _, line := range d.logs {
+		fmt.Fprintf(buffer, "  %s\n", line)
+	}
+	d.out.Write(buffer.Bytes())
+}
+
+/* histogramSummary describes brightness of a downloaded jpeg frame */
+func histogramSummary(path string) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	img, err := jpeg.Decode(fh)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("mean brightness %.1f%%", MeanBrightness(img)*100), nil
+}
+
+/* frameDone reports captured frame to the dashboard */
+func (c *Camera) frameDone(record FrameRecord) {
+	if c.TUI == nil {
+		return
+	}
+	summary := fmt.Sprintf("frame %d: %s exposure %.1fs ISO %s", record.Frame, strings.Join(record.Files, ", "), record.Actual.Seconds(), record.ISO)
+	for _, name := range record.Files {
+		if isJPEG(name) {
+			if histogram, err := histogramSummary(c.framePath(name)); err == nil {
+				summary += ", " + histogram
+			}
+			break
+		}
+	}
+	c.TUI.FrameDone(c.Label, summary)
+}
@@ -0,0 +1,79 @@
+package astrocam
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* UnexpectedDir is the subfolder of kind directory holding new camera files in excess of a single frame */
+const UnexpectedDir = "unexpected"
+
+/* expectedFiles returns number of files a single exposure produces with configured image format */
+func (c *Camera) expectedFiles() int {
+	if strings.Contains(c.ImageFormat, "+") {
+		return 2
+	}
+	return 1
+}
+
+/* splitUnexpected attributes the most recent files to the frame and returns the rest as unexpected */
+func (c *Camera) splitUnexpected(files CameraFiles) (frame CameraFiles, extra CameraFiles) {
+	expected := c.expectedFiles()
+	if len(files) <= expected {
+		return files, nil
+	}
+	/* camera lists files in capture order, files of the frame share base name of the last one */
+	last := files[len(files)-1].Name
+	base := strings.TrimSuffix(last, filepath.Ext(last))
+	for i := len(files) - 1; i >= 0; i-- {
+		name := files[i].Name
+		if len(frame) < expected && strings.TrimSuffix(name, filepath.Ext(name)) == base {
+			frame = append(CameraFiles{files[i]}, frame...)
+		} else {
+			extra = append(CameraFiles{files[i]}, extra...)
+		}
+	}
+	log.Printf("Warning: %s%d new files on the camera, expected %d, moving %d to %s\n", c.prefix(), len(files), expected, len(extra), UnexpectedDir)
+	return frame, extra
+}
+
+/* downloadUnexpected downloads files not attributed to any frame to unexpected folder */
+func (c *Camera) downloadUnexpected(ctx context.Context, files CameraFiles, downloaded map[string]bool) error {
+	if len(files) == 0 {
+		return nil
+	}
+	dir := filepath.Join(c.frameDir, UnexpectedDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("downloadUnexpected: %w", err)
+	}
+	for _, file := range files {
+		if err := c.downloadFile(ctx, file, filepath.Join(dir, file.Name)); err != nil {
+			return fmt.Errorf("downloadUnexpected: %w", err)
+		}
+		downloaded[file.Name] = true
+	}
+	return nil
+}
+
+/* collectUnexpected downloads files which appeared on the card without a complete frame, e.g. written by an exposure interrupted by disconnect */
+func (c *Camera) collectUnexpected(ctx context.Context) error {
+	files, err := c.listFiles()
+	if err != nil {
+		return err
+	}
+	newFiles := *c.Files.FindNew(files)
+	if len(newFiles) == 0 {
+		return nil
+	}
+	log.Printf("Warning: %s%d new files on the camera after reconnect, moving them to %s\n", c.prefix(), len(newFiles), UnexpectedDir)
+	downloaded := make(map[string]bool)
+	err = c.downloadUnexpected(ctx, newFiles, downloaded)
+	if e := c.deleteNewFiles(newFiles, downloaded); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
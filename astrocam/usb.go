@@ -0,0 +1,35 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	/* MinUSBTimeout and MaxUSBTimeout limit camera port i/o timeout */
+	MinUSBTimeout = time.Second
+	MaxUSBTimeout = time.Minute * 10
+	/* MinUSBChunkSize and MaxUSBChunkSize limit bulk transfer size in bytes */
+	MinUSBChunkSize = 4 << 10
+	MaxUSBChunkSize = 16 << 20
+)
+
+/* checkUSBTuning validates usb i/o tuning options */
+func (c *Camera) checkUSBTuning() error {
+	if c.USBTimeout != 0 && (c.USBTimeout < MinUSBTimeout || c.USBTimeout > MaxUSBTimeout) {
+		return fmt.Errorf("Bad 'usb-timeout' option: %v (must be between %v and %v)", c.USBTimeout, MinUSBTimeout, MaxUSBTimeout)
+	}
+	if c.USBChunkSize != 0 && (c.USBChunkSize < MinUSBChunkSize || c.USBChunkSize > MaxUSBChunkSize) {
+		return fmt.Errorf("Bad 'usb-chunk-size' option: %d (must be between %d and %d bytes)", c.USBChunkSize, MinUSBChunkSize, MaxUSBChunkSize)
+	}
+	return nil
+}
+
+/* applyUSBTuning applies usb i/o tuning to camera port, the gphoto2 binding does not expose port settings so defaults stay in effect */
+func (c *Camera) applyUSBTuning() {
+	if c.USBTimeout == 0 && c.USBChunkSize == 0 {
+		return
+	}
+	log.Printf("Warning: camera port tuning is not supported by the gphoto2 binding, ignoring -usb-timeout and -usb-chunk-size\n")
+}
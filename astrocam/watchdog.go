@@ -0,0 +1,30 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+/* watchdog runs a camera operation which may block forever inside cgo on a wedged usb bus, giving up after setting timeout */
+func (c *Camera) watchdog(op string, fn func() error) error {
+	if c.SettingTimeout <= 0 {
+		return fn()
+	}
+	/* the blocked goroutine can not be interrupted and is leaked, but the user gets feedback */
+	result := make(chan error, 1)
+	go func() {
+		result <- fn()
+	}()
+	timer := time.NewTimer(c.SettingTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		err := &CameraError{Op: op, Class: ErrDisconnected, Err: fmt.Errorf("camera not responding for %v, check usb connection and power cycle the camera", c.SettingTimeout)}
+		/* report right away, closing the wedged camera may block as well */
+		log.Printf("Error: %s%v\n", c.prefix(), err)
+		return err
+	}
+}
@@ -0,0 +1,52 @@
+package astrocam
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+)
+
+const (
+	/* DefaultWhiteBalance is the white balance preset used unless color temperature is set */
+	DefaultWhiteBalance = "Daylight"
+	/* KelvinWhiteBalance is the white balance preset taking color temperature from the colortemperature setting */
+	KelvinWhiteBalance = "Color Temperature"
+	/* MinKelvin and MaxKelvin limit color temperature accepted by -kelvin */
+	MinKelvin = 2500
+	MaxKelvin = 10000
+)
+
+/* checkKelvin validates color temperature option */
+func (c *Camera) checkKelvin() error {
+	if c.Kelvin != 0 && (c.Kelvin < MinKelvin || c.Kelvin > MaxKelvin) {
+		return fmt.Errorf("Bad 'kelvin' option: %d (must be between %d and %d)", c.Kelvin, MinKelvin, MaxKelvin)
+	}
+	return nil
+}
+
+/* setWhiteBalance sets daylight white balance or the requested color temperature */
+func (c *Camera) setWhiteBalance() error {
+	if c.Kelvin == 0 {
+		return c.SetConfig("whitebalance", DefaultWhiteBalance)
+	}
+	preset, err := c.findChoice("whitebalance", KelvinWhiteBalance)
+	if err != nil {
+		return err
+	}
+	if err := c.SetConfig("whitebalance", preset); err != nil {
+		return err
+	}
+	/* enumerated bodies accept only listed values, others take any number */
+	kelvin := strconv.Itoa(c.Kelvin)
+	if choice, err := c.findChoice("colortemperature", kelvin); err == nil {
+		kelvin = choice
+	}
+	if err := c.SetConfig("colortemperature", kelvin); err != nil {
+		return err
+	}
+	/* confirm the body accepted the value */
+	if value, err := c.GetConfig("colortemperature"); err == nil && value != kelvin {
+		log.Printf("Warning: color temperature set to %s but camera reports %s\n", kelvin, value)
+	}
+	return nil
+}
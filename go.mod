@@ -2,4 +2,4 @@ module astro
 
 go 1.19
 
-require github.com/jonmol/gphoto2 v1.0.1 // indirect
+require github.com/jonmol/gphoto2 v1.0.1
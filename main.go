@@ -0,0 +1,487 @@
+package main
+
+import (
+	"astro/astrocam"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+/* exit statuses reported to supervising scripts */
+const (
+	ExitError       = 1
+	ExitUsage       = 2
+	ExitInterrupted = astrocam.ExitInterrupted
+	ExitLocked      = 4
+)
+
+/* exitStatus returns exit status of a failed session */
+func exitStatus(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return ExitInterrupted
+	}
+	return ExitError
+}
+
+/* explicitFlags returns names of flags explicitly set by user */
+func explicitFlags() map[string]bool {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
+
+/* lockTarget takes the session lock of target directory, with force a lock held by another instance is only reported */
+func lockTarget(target string, force bool) (release func(), ok bool) {
+	release, err := astrocam.AcquireLock(target)
+	if err == nil {
+		return release, true
+	}
+	if !force {
+		log.Print(err)
+		return nil, false
+	}
+	log.Printf("Warning: %v, continuing anyway\n", err)
+	return func() {}, true
+}
+
+/* handleInterrupt stops all sessions after the current frame on sigint, a second sigint releases shutters, restores settings and exits at once; returned function ends interrupt handling */
+func handleInterrupt(stop *astrocam.StopSignal, cameras []*astrocam.Camera) func() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-interrupt:
+			}
+			if !stop.Stopped() {
+				stop.Stop()
+				fmt.Printf("\nStopping after the current frame, interrupt again to exit immediately\n")
+				continue
+			}
+			/* the frame in progress is left on the card for -recover */
+			fmt.Printf("\nAborting, releasing shutter\n")
+			astrocam.AbortSessions(cameras)
+			os.Exit(ExitInterrupted)
+		}
+	}()
+	return func() {
+		signal.Stop(interrupt)
+		close(done)
+	}
+}
+
+/* run parses command line and runs the requested command, it returns exit status so that deferred cleanup runs before exit */
+func run() int {
+	camera := astrocam.New()
+	flag.IntVar(&camera.Frames, "frames", camera.Frames, "Number of images to take or 0 for no limit (default: 0)")
+	flag.StringVar(&camera.Target, "target", camera.Target, "Name of target directory to download images to")
+	flag.IntVar(&camera.Duration, "duration", camera.Duration, "Length of frames to take (default: 60s)")
+	flag.StringVar(&camera.Shutter, "shutter", camera.Shutter, "Set the specified camera shutter speed (default: 'bulb')")
+	flag.Float64Var(&camera.Aperture, "aperture", camera.Aperture, "Lens aperture ratio (default: 2.8)")
+	flag.StringVar(&camera.ISO, "iso", camera.ISO, "ISO value or 'auto' (default: 800)")
+	flag.StringVar(&camera.Kind, "kind", camera.Kind, "Specify lights, darks, flats or bias frames capturing (default: lights)")
+	flag.BoolVar(&camera.Keep, "keep", camera.Keep, "Keep files on the camera after download, same as -delete-policy=none (default: remove files)")
+	flag.StringVar(&camera.DeletePolicy, "delete-policy", camera.DeletePolicy, "Remove 'downloaded' files, 'all-new' files (even if download failed) or 'none' from the camera")
+	flag.DurationVar(&camera.DeleteDelay, "capture-delay-after-download", camera.DeleteDelay, "Wait after verified download before removing files from the camera (default: 1s)")
+	flag.BoolVar(&camera.Append, "append", camera.Append, "Add frames to kind directory which already contains files")
+	flag.BoolVar(&camera.NewRun, "new-run", camera.NewRun, "Capture to a new numbered run subfolder (e.g. lights/run001) when kind directory already contains files")
+	flag.Var(&camera.DurationRamp, "ramp-duration", "Change bulb duration smoothly from start to end seconds across -frames, e.g. '1:30' (default: disabled)")
+	flag.Var(&camera.ISORamp, "ramp-iso", "Change iso smoothly from start to end value across -frames, e.g. '100:3200' (default: disabled)")
+	flag.BoolVar(&camera.Sweep, "sweep", camera.Sweep, "Build darks or bias library capturing -frames of each -iso-bracket and -bracket combination")
+	flag.Var(&camera.ISOBracket, "iso-bracket", "Comma separated iso values swept by -sweep, e.g. '400,800,1600' (default: -iso)")
+	flag.Var(&camera.Bracket, "bracket", "Comma separated durations in seconds swept by -sweep, e.g. '60,120,300' (default: -duration)")
+	flag.Var(&camera.Brackets, "brackets", "Comma separated durations in seconds each frame is exposed with, e.g. '30,60,120' (default: -duration)")
+	flag.IntVar(&camera.DarkEvery, "dark-every", camera.DarkEvery, "Capture a dark frame into darks directory after every N lights or 0 to disable (default: 0)")
+	flag.IntVar(&camera.RefocusEvery, "refocus-every", camera.RefocusEvery, "Check focus every N frames or 0 to disable (default: 0)")
+	flag.Float64Var(&camera.RefocusThreshold, "refocus-threshold", camera.RefocusThreshold, "Focus score drop in percent to warn about (default: 20)")
+	flag.StringVar(&camera.AutofocusCmd, "autofocus-cmd", camera.AutofocusCmd, "External command to run when focus has drifted (default: '')")
+	flag.DurationVar(&camera.MirrorPrefire, "mirror-prefire", camera.MirrorPrefire, "Send -prefire-sequence and wait the specified delay before each exposure to let mirror vibrations settle (default: 0, disabled)")
+	flag.Var(&camera.PrefireSequence, "prefire-sequence", "Comma separated remote release states sent by -mirror-prefire (default: 'Press Half,Release Half')")
+	flag.Var(&camera.ReleaseSequence, "release-sequence", "Comma separated remote release states to end exposure, e.g. 'Release Half,Release Full'")
+	flag.BoolVar(&camera.AssumeYes, "yes", camera.AssumeYes, "Do not ask for confirmation of destructive commands")
+	flag.BoolVar(&camera.AutoFlats, "auto-flats", camera.AutoFlats, "Meter flats shutter speed from preview frames before capturing")
+	flag.Float64Var(&camera.FlatsBrightness, "flats-brightness", camera.FlatsBrightness, "Target mean brightness of metered flats in range 0-1 (default: 0.5)")
+	flag.BoolVar(&camera.SaveTestFrames, "save-test-frames", camera.SaveTestFrames, "Save metering test frames to the target directory (default: discard)")
+	flag.IntVar(&camera.SelfTimer, "self-timer", camera.SelfTimer, "Use camera 2 or 10 second self-timer drive mode to let vibrations settle after release or 0 to disable (default: 0)")
+	flag.BoolVar(&camera.SkipDriveMode, "skip-drivemode", camera.SkipDriveMode, "Do not switch camera drive mode to single (default: switch and restore on exit)")
+	flag.BoolVar(&camera.SanityCheck, "sanity-check", camera.SanityCheck, "Verify preview brightness before capturing lights or darks")
+	flag.BoolVar(&camera.SanityAbort, "sanity-abort", camera.SanityAbort, "Abort session when sanity check fails (default: warn)")
+	flag.DurationVar(&camera.ConnectTimeout, "connect-timeout", camera.ConnectTimeout, "Keep polling for camera connection for the specified duration, e.g. '2m' (default: no retry)")
+	flag.DurationVar(&camera.ConnectInterval, "connect-interval", camera.ConnectInterval, "Interval between camera connection attempts (default: 5s)")
+	flag.DurationVar(&camera.PretriggerDelay, "pretrigger-delay", camera.PretriggerDelay, "Delay before each exposure start, multiplied by camera index in multi camera sessions (default: 0)")
+	flag.StringVar(&camera.PowerCycleCmd, "power-cycle-cmd", camera.PowerCycleCmd, "Shell command power cycling the camera on persistent i/o errors, e.g. via switchable usb hub (default: '')")
+	flag.DurationVar(&camera.PowerCycleDelay, "power-cycle-delay", camera.PowerCycleDelay, "Wait for the camera to boot after power cycle before reconnecting (default: 10s)")
+	flag.IntVar(&camera.ShutterRating, "shutter-rating", camera.ShutterRating, "Rated shutter life in actuations, warn when shutter count gets close to it (default: 0, disabled)")
+	flag.IntVar(&camera.Delay, "delay", camera.Delay, "Pause for the specified number of seconds between exposures to let vibrations settle (default: 0)")
+	flag.StringVar(&camera.DitherCmd, "dither-cmd", camera.DitherCmd, "Shell command dithering the mount between frames (default: '', no dithering)")
+	flag.IntVar(&camera.DitherEvery, "dither-every", camera.DitherEvery, "Run -dither-cmd after every N frames (default: 1)")
+	flag.IntVar(&camera.DitherSettle, "dither-settle", camera.DitherSettle, "Wait for the specified number of seconds after dithering before the next exposure (default: 0)")
+	flag.IntVar(&camera.CooldownEvery, "cooldown-every", camera.CooldownEvery, "Pause for -cooldown-duration every N frames to reduce sensor heat or 0 to disable (default: 0)")
+	flag.DurationVar(&camera.CooldownDuration, "cooldown-duration", camera.CooldownDuration, "Length of cooldown pause enabled by -cooldown-every (default: 5m)")
+	flag.DurationVar(&camera.Cadence, "cadence", camera.Cadence, "Start frames at exact multiples of the interval from session start, e.g. '2m' (default: 0, back to back)")
+	flag.BoolVar(&camera.CadenceStrict, "cadence-strict", camera.CadenceStrict, "Abort session when a frame overruns -cadence (default: warn)")
+	flag.DurationVar(&camera.ReleaseHold, "release-hold", camera.ReleaseHold, "Minimal time between starting and releasing a bulb exposure (default: 100ms)")
+	flag.StringVar(&camera.ExposureStatus, "exposure-status", camera.ExposureStatus, "Camera setting whose value changes while exposing, used to confirm exposure start and end (default: '', timed)")
+	flag.BoolVar(&camera.NoReset, "no-reset", camera.NoReset, "Do not reset camera connection before listing new files")
+	flag.Int64Var(&camera.MaxFrameSize, "max-frame-size", camera.MaxFrameSize, "Skip camera files larger than the specified size in MB or 0 for no limit (default: 1024)")
+	flag.DurationVar(&camera.SettingTimeout, "setting-timeout", camera.SettingTimeout, "Give up when camera does not answer initial setting reads within the specified time or 0 to wait forever (default: 30s)")
+	flag.IntVar(&camera.MaxDownloadFailures, "max-download-failures", camera.MaxDownloadFailures, "End session after N consecutive frames fail to download or 0 to never end it (default: 3)")
+	flag.DurationVar(&camera.USBTimeout, "usb-timeout", camera.USBTimeout, "Camera usb i/o timeout for slow or long cable links, e.g. '30s' (default: 0, gphoto2 default)")
+	flag.IntVar(&camera.USBChunkSize, "usb-chunk-size", camera.USBChunkSize, "Camera usb bulk transfer size in bytes (default: 0, gphoto2 default)")
+	flag.StringVar(&camera.HealthFile, "healthfile", camera.HealthFile, "Touch the specified file at session start and after each frame for external watchdogs (default: '')")
+	flag.IntVar(&camera.Retries, "retries", camera.Retries, "Number of retries of failed downloads and transient camera errors of release and reset (default: 3)")
+	flag.BoolVar(&camera.DateLayout, "date-layout", camera.DateLayout, "Download frames to target/YYYY-MM-DD/kind directories")
+	flag.BoolVar(&camera.DateRotate, "date-rotate", camera.DateRotate, "Switch date directory when local date changes during capture (requires -date-layout)")
+	flag.StringVar(&camera.ImageFormat, "imageformat", camera.ImageFormat, "Camera image format, e.g. 'RAW' or 'RAW + Large Fine JPEG' (default: RAW)")
+	flag.StringVar(&camera.PictureStyle, "picturestyle", camera.PictureStyle, "Set picture style of jpeg files and previews, e.g. 'Neutral' or 'Faithful' (default: unchanged)")
+	flag.StringVar(&camera.Filter, "filter", camera.Filter, "Name of the filter in use, recorded in acquisition log (default: '')")
+	flag.Var(&camera.Crop, "crop", "Intended framing as x,y,w,h in frame pixels, recorded in summary and sidecars and used for cropped jpeg preview (default: '')")
+	flag.Var(&camera.Filters, "filters", "Capture the specified number of frames through each filter in turn to filter subfolders, e.g. 'Ha:20,OIII:20' (default: '')")
+	filterCmd := flag.String("filter-cmd", "", "Shell command selecting filter passed as $1 on filter wheel (default: '', filters are changed manually)")
+	filterPositionCmd := flag.String("filter-position-cmd", "", "Shell command printing name of the filter in place, used to verify filter changes (default: '')")
+	flag.StringVar(&camera.AstroBinCSV, "astrobin-csv", camera.AstroBinCSV, "Write acquisition details in AstroBin csv import format to the specified file at session end (default: '')")
+	flag.IntVar(&camera.Kelvin, "kelvin", camera.Kelvin, "Set white balance to the specified color temperature in Kelvin or 0 for daylight (default: 0)")
+	flag.StringVar(&camera.PairLayout, "pairs", camera.PairLayout, "Keep raw+jpeg pairs 'together' in kind directory or 'split' them to raw and jpeg subfolders")
+	flag.BoolVar(&camera.JSONInfo, "json-info", camera.JSONInfo, "Print startup camera info as a single json object instead of the banner")
+	flag.BoolVar(&camera.Sidecar, "sidecar", camera.Sidecar, "Write json metadata sidecar file next to each downloaded frame")
+	flag.IntVar(&camera.RunningPreview, "running-preview", camera.RunningPreview, "Write average of downloaded jpeg frames as png every N frames or 0 to disable (default: 0)")
+	flag.BoolVar(&camera.ToDNG, "to-dng", camera.ToDNG, "Convert downloaded raw frames to dng in background, raw is kept if conversion fails")
+	flag.StringVar(&camera.DNGCmd, "dng-cmd", camera.DNGCmd, "Shell command converting raw file $1 to dng file $2")
+	flag.BoolVar(&camera.DNGKeepRaw, "dng-keep-raw", camera.DNGKeepRaw, "Keep original raw next to converted dng (default: remove raw)")
+	fits := flag.Bool("fits", false, "Convert downloaded raw frames to fits with exposure headers instead of keeping camera raw files")
+	fitsCmd := flag.String("fits-cmd", "rawtran -o \"$2\" \"$1\"", "Shell command converting raw file $1 to fits file $2, used by -fits")
+	flag.BoolVar(&camera.FITSKeepRaw, "fits-keep-raw", camera.FITSKeepRaw, "Keep original raw next to fits file (default: remove raw)")
+	flag.StringVar(&camera.Telescope, "telescope", camera.Telescope, "Name of the telescope recorded in TELESCOP header of fits files (default: '')")
+	flag.BoolVar(&camera.Timelapse, "timelapse", camera.Timelapse, "Assemble downloaded jpeg frames into a timelapse video after capture")
+	flag.IntVar(&camera.TimelapseFPS, "timelapse-fps", camera.TimelapseFPS, "Framerate of the timelapse video (default: 25)")
+	flag.StringVar(&camera.FFmpeg, "ffmpeg", camera.FFmpeg, "Path to ffmpeg executable used for timelapse assembly")
+	flag.BoolVar(&camera.UseInternalBulb, "use-internal-bulb", camera.UseInternalBulb, "Time bulb exposures with camera internal bulb timer where supported")
+	profile := flag.String("profile", "", "Load capture parameters from the named profile or json profile file, e.g. 'm42.json', flags override profile values")
+	saveProfile := flag.String("save-profile", "", "Save effective capture parameters to the named profile or json profile file")
+	flag.Float64Var(&camera.MinFreeSpace, "min-free-space", camera.MinFreeSpace, "Remove oldest frames stored by sink when target free space drops below percent (default: 0, disabled)")
+	flag.Int64Var(&camera.RateLimit, "rate-limit", camera.RateLimit, "Limit download throughput to target on a network filesystem to the specified bytes per second (default: 0, unlimited)")
+	flag.BoolVar(&camera.RateLimitLocal, "rate-limit-local", camera.RateLimitLocal, "Apply -rate-limit to target on a local filesystem too")
+	flag.BoolVar(&camera.ACPower, "ac-power", camera.ACPower, "Camera is powered by an AC adapter or dummy battery, disables battery estimates and warnings (default: false)")
+	flag.Float64Var(&camera.BatteryPerFrame, "battery-per-frame", camera.BatteryPerFrame, "Battery percent used by a single frame for remaining frames estimate until measured during session (default: 0.2)")
+	sinkCmd := flag.String("sink-cmd", "", "Shell command storing each downloaded frame passed as $1, e.g. 'rsync -a \"$1\" host:/data/'")
+	cloudCmd := flag.String("cloud-cmd", "", "Shell command exiting with status 0 for clear sky and 1 for clouds, capture pauses while cloudy (default: '')")
+	flag.DurationVar(&camera.CloudTimeout, "cloud-timeout", camera.CloudTimeout, "End session when sky does not clear within the specified duration (default: 0, wait indefinitely)")
+	flag.DurationVar(&camera.CloudInterval, "cloud-interval", camera.CloudInterval, "Interval between sky checks while paused (default: 1m)")
+	moonGate := flag.Bool("moon-gate", false, "Pause capture while the moon is up, requires -location (default: false)")
+	moonMaxAltitude := flag.Float64("moon-max-altitude", 0, "Highest moon altitude in degrees allowed by -moon-gate (default: 0)")
+	moonMaxPhase := flag.Float64("moon-max-phase", 0, "Moon illumination in percent below which -moon-gate allows capture regardless of altitude (default: 0)")
+	match := flag.String("match", "", "Warn if iso or duration differ from frames in the specified lights directory (default: '')")
+	location := flag.String("location", "", "Record location from fixed 'lat,lon' coordinates or 'gpsd[:host:port]' (default: '')")
+	force := flag.Bool("force", false, "Run even if another instance uses the same target directory")
+	tui := flag.Bool("tui", false, "Display full screen dashboard instead of the status line")
+	frameLog := flag.String("log", "", "Append exposure parameters, battery level and file name of each frame as json lines to the specified file (default: '')")
+	jsonEvents := flag.String("json-events", "", "Append session events as json lines to the specified file or '-' for stdout (default: '')")
+	metricsAddr := flag.String("metrics", "", "Serve prometheus metrics on the specified address, e.g. ':9090' (default: '')")
+	statusSocket := flag.String("status-socket", "", "Write capture status as json lines to a named pipe or unix socket (default: '')")
+	flag.BoolVar(&camera.OptimizePower, "optimize-power", camera.OptimizePower, "Disable camera auto power off and image review during session (restored on exit)")
+	cameraName := flag.String("name", "", "Comma separated names of cameras to use (default: '')")
+	selfTest := flag.Bool("selftest", false, "Test all camera settings used by astro without capturing images and exit")
+	framing := flag.Bool("framing", false, "Write liveview preview with framing grid overlay to target directory and exit")
+	resumeFromCard := flag.Bool("resume-from-card", false, "Continue a session from frames found in kind directory when its state file is lost, using settings of the last frame")
+	planFile := flag.String("json-plan", "", "Capture blocks of the specified json plan file in order, each with its own object, filter, kind, iso, duration, frames and start time (default: '')")
+	liveview := flag.String("liveview", "", "Serve camera liveview as mjpeg stream for framing and focus on the specified address, e.g. ':8081', instead of capturing (default: '')")
+	describe := flag.String("describe", "", "Print current value, type and choices of the named camera setting, e.g. 'shutterspeed', and exit (default: '')")
+	bench := flag.Int("bench", 0, "Measure download throughput and per-frame overhead over N short captures and exit (default: 0)")
+	flag.BoolVar(&camera.DeferDownload, "defer-download", camera.DeferDownload, "Leave frames on the camera card during the session and download them all when capture is complete")
+	recoverPartial := flag.Bool("recover", false, "Download frames of an interrupted session left on the camera card and exit")
+	verify := flag.Bool("verify", false, "Verify downloaded frames in target directory against checksums.txt and exit")
+	formatCard := flag.Bool("format-card", false, "Remove all files from the camera card after verifying they were downloaded to target and exit")
+	flag.Parse()
+	camera.Explicit = explicitFlags()
+	if *metricsAddr != "" {
+		camera.Metrics = astrocam.NewMetrics()
+		astrocam.ServeMetrics(*metricsAddr, camera.Metrics)
+	}
+	if *statusSocket != "" {
+		camera.StatusOutput = astrocam.NewStatusWriter(*statusSocket)
+	}
+	if *tui {
+		camera.TUI = astrocam.NewDashboard(os.Stdout)
+		log.SetOutput(camera.TUI)
+		defer camera.TUI.Close()
+	}
+	if *frameLog != "" {
+		frames, err := astrocam.NewFrameLog(*frameLog)
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		camera.FrameLog = frames
+	}
+	if *jsonEvents != "" {
+		events, err := astrocam.NewEventWriter(*jsonEvents)
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		camera.Events = events
+		/* logged warnings and errors become events as well */
+		log.SetOutput(io.MultiWriter(log.Writer(), events))
+	}
+	if *cloudCmd != "" {
+		camera.Clouds = astrocam.CommandCloudDetector{Command: *cloudCmd}
+	}
+	if *filterCmd != "" {
+		camera.FilterWheel = astrocam.CommandFilterWheel{Command: *filterCmd, PositionCommand: *filterPositionCmd}
+	}
+	if *fits {
+		camera.FITS = astrocam.CommandFITSConverter{Command: *fitsCmd}
+	}
+	if *sinkCmd != "" {
+		camera.Sink = astrocam.CommandSink{Command: *sinkCmd}
+	}
+	if *location != "" {
+		source, err := astrocam.ParseLocationSource(*location)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return ExitUsage
+		}
+		camera.LocationSource = source
+	}
+	if *moonGate {
+		if camera.LocationSource == nil {
+			fmt.Printf("Option -moon-gate requires -location\n")
+			return ExitUsage
+		}
+		camera.Ephemeris = astrocam.MoonGate{Source: camera.LocationSource, MaxAltitude: *moonMaxAltitude, MaxPhase: *moonMaxPhase}
+	}
+	/* profile applies to target directory commands as well as to capture session */
+	if *profile != "" {
+		if err := camera.LoadProfile(*profile); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+	}
+	/* self test command checks camera compatibility */
+	if *selfTest {
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		report, err := camera.RunSelfTest()
+		camera.Close()
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		fmt.Printf("Self test:\n")
+		report.Print()
+		if !report.Passed() {
+			return ExitError
+		}
+		return 0
+	}
+	/* target directory commands take the session lock so they do not race a running capture */
+	if *verify {
+		release, ok := lockTarget(camera.Target, *force)
+		if !ok {
+			return ExitLocked
+		}
+		defer release()
+		verified, failures, err := astrocam.VerifyChecksums(camera.Target)
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		for _, failure := range failures {
+			fmt.Printf("FAILED %s\n", failure)
+		}
+		fmt.Printf("Verified %d files, %d failed.\n", verified, len(failures))
+		if len(failures) != 0 {
+			return ExitError
+		}
+		return 0
+	}
+	if *framing {
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		path, err := camera.WriteFramingPreview()
+		camera.Close()
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		fmt.Printf("Framing preview written to %s\n", path)
+		return 0
+	}
+	if *liveview != "" {
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		err := camera.ServeLiveview(*liveview)
+		camera.Close()
+		log.Print(err)
+		return ExitError
+	}
+	if *describe != "" {
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		description, err := camera.DescribeSetting(*describe)
+		camera.Close()
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		description.Print()
+		return 0
+	}
+	if *bench > 0 {
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		report, err := camera.RunBench(*bench)
+		camera.Close()
+		report.Print()
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		return 0
+	}
+	if *recoverPartial {
+		release, ok := lockTarget(camera.Target, *force)
+		if !ok {
+			return ExitLocked
+		}
+		defer release()
+		if err := camera.CheckDeletePolicy(); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		err := camera.RecoverPartial(context.Background())
+		camera.Close()
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		return 0
+	}
+	/* format card command never runs as a part of capture session */
+	if *formatCard {
+		release, ok := lockTarget(camera.Target, *force)
+		if !ok {
+			return ExitLocked
+		}
+		defer release()
+		if err := camera.Connect(*cameraName); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		err := camera.FormatCard()
+		camera.Close()
+		if err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		return 0
+	}
+	/* continue from frames already in kind directory before presets are applied */
+	if *resumeFromCard {
+		if camera.NewRun || camera.Sweep {
+			fmt.Printf("Option -resume-from-card can not be used with -new-run or -sweep\n")
+			return ExitUsage
+		}
+		if err := camera.ResumeFromFrames(); err != nil {
+			fmt.Printf("%v\n", err)
+			return ExitUsage
+		}
+	}
+	/* sanity checks */
+	if err := camera.Configure(); err != nil {
+		fmt.Printf("%v\n", err)
+		return ExitUsage
+	}
+	if *match != "" {
+		camera.CheckMatch(*match)
+	}
+	shootingTime := camera.Frames * camera.BracketTime()
+	if camera.Sweep {
+		total, err := camera.CheckSweep()
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return ExitUsage
+		}
+		shootingTime = total
+	}
+	if *planFile != "" {
+		if camera.Sweep || len(camera.Filters) != 0 || camera.DeferDownload || *resumeFromCard {
+			fmt.Printf("Option -json-plan can not be used with -sweep, -filters, -defer-download or -resume-from-card\n")
+			return ExitUsage
+		}
+		plan, err := astrocam.LoadPlan(*planFile)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return ExitUsage
+		}
+		camera.UsePlan(plan)
+		shootingTime = plan.ShootingTime()
+	}
+	if len(camera.Filters) != 0 {
+		total, err := camera.CheckFilters()
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return ExitUsage
+		}
+		shootingTime = total * camera.BracketTime()
+	}
+	if shootingTime > 28800 {
+		fmt.Printf("Specified shooting time is longer than 8 hours, aborting.\n")
+		return ExitUsage
+	}
+	if *saveProfile != "" {
+		if err := camera.SaveProfile(*saveProfile); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+	}
+	/* refuse to share target directory with another instance */
+	release, ok := lockTarget(camera.Target, *force)
+	if !ok {
+		return ExitLocked
+	}
+	defer release()
+	/* run capture sessions, all cameras stop together */
+	camera.Stop = astrocam.NewStopSignal()
+	cameras, err := astrocam.NewSessions(camera, strings.Split(*cameraName, ","))
+	if err != nil {
+		log.Print(err)
+		return ExitError
+	}
+	defer handleInterrupt(camera.Stop, cameras)()
+	if err := astrocam.RunSessions(context.Background(), cameras); err != nil {
+		log.Print(err)
+		return exitStatus(err)
+	}
+	return 0
+}
+
+/* main program */
+func main() {
+	os.Exit(run())
+}